@@ -3,38 +3,38 @@ package main
 import (
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/gbrlsnchs/cli"
 	"github.com/gbrlsnchs/cli/cliutil"
-	"github.com/gbrlsnchs/pilgo/config"
 	"github.com/gbrlsnchs/pilgo/fs"
 	"github.com/gbrlsnchs/pilgo/linker"
 	"github.com/gbrlsnchs/pilgo/parser"
-	"gopkg.in/yaml.v3"
+)
+
+var (
+	errLinkNotExist  = errors.New("link doesn't exist")
+	errMissingLinks  = errors.New("there are missing links")
+	errUnknownFailOn = errors.New("unknown fail mode")
 )
 
 type checkCmd struct {
-	fail bool
-	tags cliutil.CommaSepOptionSet
+	fail    failMode
+	jobs    int
+	summary bool
+	tags    cliutil.CommaSepOptionSet
+	print   printMode
 }
 
 func (cmd *checkCmd) register(getcfg func() appConfig) cli.ExecFunc {
 	return func(prg cli.Program) error {
 		appcfg := getcfg()
 		fs := fs.New(appcfg.fs)
-		b, err := fs.ReadFile(appcfg.conf)
+		c, err := appcfg.readConfig(prg)
 		if err != nil {
 			return err
 		}
-		c := new(config.Config)
-		if yaml.Unmarshal(b, c); err != nil {
-			return err
-		}
-		userConfigDir, err := appcfg.userConfigDir()
-		if err != nil {
-			return err
-		}
-		homeConfigDir, err := appcfg.userHomeDir()
+		baseDirs, err := appcfg.baseDirs()
 		if err != nil {
 			return err
 		}
@@ -44,36 +44,145 @@ func (cmd *checkCmd) register(getcfg func() appConfig) cli.ExecFunc {
 		}
 		var p parser.Parser
 		tr, err := p.Parse(c,
-			parser.BaseDirs(map[parser.Mode]string{
-				parser.UserMode: userConfigDir,
-				parser.HomeMode: homeConfigDir,
-			}),
+			parser.BaseDirs(baseDirs),
 			parser.Cwd(cwd),
 			parser.Envsubst,
 			parser.Tags(cmd.tags))
 		if err != nil {
 			return err
 		}
-		ln := linker.New(fs)
-		if err = ln.Resolve(tr); err != nil {
-			var cft *linker.ConflictError
-			if errors.As(err, &cft) {
-				if !cmd.fail {
-					goto printtree
-				}
-				name := prg.Name()
-				errw := prg.Stderr()
-				for _, err := range cft.Errs {
-					fmt.Fprintf(errw, "%s: %v\n", name, err)
+		verr := tr.Validate()
+		var cerr *parser.CollisionError
+		if verr != nil && !errors.As(verr, &cerr) {
+			return verr
+		}
+		ln := linker.New(fs, concurrency(cmd.jobs))
+		err = ln.Resolve(tr)
+		var cft *linker.ConflictError
+		if err != nil && !errors.As(err, &cft) {
+			return err
+		}
+		if cmd.fail != failNever {
+			name := prg.Name()
+			errw := prg.Stderr()
+			if cerr != nil {
+				fprintCollisions(errw, name, cerr)
+			}
+			if cft != nil {
+				fprintConflicts(errw, name, cft)
+				return err
+			}
+			if cerr != nil {
+				return verr
+			}
+			if cmd.fail == failReady {
+				missing := false
+				tr.Walk(func(n *parser.Node) error {
+					if n.Status == parser.StatusReady {
+						fmt.Fprintf(errw, "%s: %s: %v\n", name, n.Link.FullPath(), errLinkNotExist)
+						missing = true
+					}
+					return nil
+				})
+				if missing {
+					return errMissingLinks
 				}
 			}
-			return err
+			return nil
 		}
-		if cmd.fail {
+		stdout := prg.Stdout()
+		sum := summarize(tr)
+		if cmd.summary {
+			fmt.Fprintln(stdout, sum)
 			return nil
 		}
-	printtree:
-		fmt.Fprint(prg.Stdout(), tr)
+		if err := cmd.print.fprint(stdout, tr, appcfg.getenv); err != nil {
+			return err
+		}
+		if cmd.print.isTree() {
+			fmt.Fprintf(stdout, "\n%s\n", sum)
+		}
+		return nil
+	}
+}
+
+type failMode int
+
+const (
+	failNever failMode = iota
+	failConflict
+	failReady
+)
+
+func (md *failMode) Set(value string) error {
+	if value == "ready" {
+		*md = failReady
+		return nil
+	}
+	fail, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("%w %q", errUnknownFailOn, value)
+	}
+	*md = failNever
+	if fail {
+		*md = failConflict
+	}
+	return nil
+}
+
+func (md *failMode) String() string {
+	switch *md {
+	case failConflict:
+		return "true"
+	case failReady:
+		return "ready"
+	default:
+		return "false"
+	}
+}
+
+func (md *failMode) IsBoolFlag() bool { return true }
+
+type summary struct {
+	done, ready, conflicts, errs, duplicates int
+}
+
+func summarize(tr *parser.Tree) summary {
+	var sum summary
+	tr.Walk(func(n *parser.Node) error {
+		switch n.Status {
+		case parser.StatusDone:
+			sum.done++
+		case parser.StatusReady:
+			sum.ready++
+		case parser.StatusConflict:
+			sum.conflicts++
+		case parser.StatusError:
+			sum.errs++
+		case parser.StatusDuplicate:
+			sum.duplicates++
+		}
 		return nil
+	})
+	return sum
+}
+
+func (sum summary) String() string {
+	s := fmt.Sprintf("%d done, %d ready, %d %s, %d %s",
+		sum.done,
+		sum.ready,
+		sum.conflicts, plural(sum.conflicts, "conflict"),
+		sum.errs, plural(sum.errs, "error"))
+	// Duplicates are rare, so they're only mentioned when there are any.
+	if sum.duplicates > 0 {
+		s += fmt.Sprintf(", %d %s", sum.duplicates, plural(sum.duplicates, "duplicate"))
+	}
+	return s
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
 	}
+	return word + "s"
 }
@@ -283,7 +283,7 @@ func TestCheck(t *testing.T) {
 					},
 				},
 			},
-			cmd: checkCmd{fail: true},
+			cmd: checkCmd{fail: failConflict},
 			want: fstest.InMemoryDriver{
 				CurrentDir: "home/dotfiles",
 				Files: map[string]fstest.File{
@@ -794,7 +794,7 @@ func TestCheck(t *testing.T) {
 				},
 			},
 			cmd: checkCmd{
-				fail: true,
+				fail: failConflict,
 				tags: cliutil.CommaSepOptionSet{
 					"test": struct{}{},
 				},
@@ -913,7 +913,7 @@ func TestCheck(t *testing.T) {
 				},
 			},
 			cmd: checkCmd{
-				fail: true,
+				fail: failConflict,
 				tags: nil,
 			},
 			want: fstest.InMemoryDriver{
@@ -1205,6 +1205,7 @@ func TestCheck(t *testing.T) {
 				appcfg = appConfig{
 					conf:          filepath.Base(t.Name()) + ".yml",
 					fs:            &tc.drv,
+					getenv:        func(string) string { return "" },
 					getwd:         func() (string, error) { return fstest.AbsPath("home", "dotfiles"), nil },
 					userConfigDir: func() (string, error) { return fstest.AbsPath("home", "config"), nil },
 					userHomeDir:   func() (string, error) { return fstest.AbsPath("home"), nil },
@@ -1233,6 +1234,11 @@ func TestCheck(t *testing.T) {
 			for _, out := range outputs {
 				t.Run(out, func(t *testing.T) {
 					golden := filepath.Join(testdir, t.Name()+".golden")
+					if *update {
+						if err := writeGolden(golden, gots[out]); err != nil {
+							t.Fatal(err)
+						}
+					}
 					b, err := readFile(golden)
 					if err != nil {
 						t.Log(err) // err means output should be empty
@@ -502,6 +502,31 @@ func TestConfig(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "conflicting_base_and_useHome",
+			drv: fstest.InMemoryDriver{
+				Files: map[string]fstest.File{
+					"conflicting_base_and_useHome.yml": {
+						Perm: os.ModePerm,
+						Data: yamlData(config.Config{Targets: []string{"foo"}}),
+					},
+				},
+			},
+			cmd: configCmd{
+				file:    "foo",
+				base:    "data",
+				useHome: boolptr{addr: internal.NewBool(true)},
+			},
+			want: fstest.InMemoryDriver{
+				Files: map[string]fstest.File{
+					"conflicting_base_and_useHome.yml": {
+						Perm: os.ModePerm,
+						Data: yamlData(config.Config{Targets: []string{"foo"}}),
+					},
+				},
+			},
+			err: config.ErrConflictingFields,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
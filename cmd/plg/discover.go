@@ -0,0 +1,108 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/gbrlsnchs/pilgo/config"
+	"github.com/gbrlsnchs/pilgo/fs"
+)
+
+const configEnv = "PILGO_CONFIG"
+
+// discover is like copy, but also looks for the configuration file when it's not set
+// with -config. The file is looked up in the following order:
+//
+//  1. The path in PILGO_CONFIG.
+//  2. Default names in the working directory and then in its parents, like Git does.
+//  3. "pilgo/config.yml" in the user configuration directory, which is usually a
+//     symlink to the configuration file in the dotfiles repository.
+//
+// Targets are then relative to the directory containing the configuration file or,
+// if it's a symlink, the one containing the file it points to. If nothing is found,
+// the default name in the working directory is used, as if no lookup happened.
+func (cfg *appConfig) discover() appConfig {
+	c := cfg.copy()
+	if cfg.conf != "" {
+		return c
+	}
+	cwd, err := c.getwd()
+	if err != nil {
+		return c
+	}
+	conf := cfg.getenv(configEnv)
+	if conf != "" {
+		if !filepath.IsAbs(conf) {
+			conf = filepath.Join(cwd, conf)
+		}
+		// A missing file is only reported when it's read.
+		if fi, err := c.fs.Stat(conf); err == nil {
+			conf = resolveLink(conf, fi)
+		}
+	}
+	if conf == "" {
+		conf = c.lookup(cwd)
+	}
+	if conf == "" {
+		conf = c.fallback()
+	}
+	if conf == "" {
+		return c
+	}
+	dir := filepath.Dir(conf)
+	if dir == filepath.Clean(cwd) {
+		// Keep paths as short as they'd be without looking up files.
+		c.conf = filepath.Base(conf)
+		return c
+	}
+	c.conf = conf
+	c.getwd = func() (string, error) { return dir, nil }
+	return c
+}
+
+// lookup looks for configuration files in dirname and its parents.
+func (cfg appConfig) lookup(dirname string) string {
+	for {
+		for _, name := range config.DefaultNames() {
+			conf := filepath.Join(dirname, name)
+			fi, err := cfg.fs.Stat(conf)
+			if err != nil {
+				return ""
+			}
+			if fi.Exists() && !fi.IsDir() {
+				return conf
+			}
+		}
+		parent := filepath.Dir(dirname)
+		if parent == dirname {
+			return ""
+		}
+		dirname = parent
+	}
+}
+
+// fallback returns the configuration file in the user configuration directory,
+// or the file it points to, if it's a symlink.
+func (cfg appConfig) fallback() string {
+	dir, err := cfg.userConfigDir()
+	if err != nil {
+		return ""
+	}
+	conf := filepath.Join(dir, "pilgo", "config.yml")
+	fi, err := cfg.fs.Stat(conf)
+	if err != nil || !fi.Exists() {
+		return ""
+	}
+	return resolveLink(conf, fi)
+}
+
+// resolveLink returns the file name points to, if it's a symlink, or name otherwise.
+func resolveLink(name string, fi fs.FileInfo) string {
+	linkname := fi.Linkname()
+	if linkname == "" {
+		return name
+	}
+	if !filepath.IsAbs(linkname) {
+		linkname = filepath.Join(filepath.Dir(name), linkname)
+	}
+	return linkname
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/config"
+	"github.com/gbrlsnchs/pilgo/fs/fstest"
+)
+
+func TestDiscover(t *testing.T) {
+	dotfiles := func(conf string) map[string]fstest.File {
+		files := map[string]fstest.File{
+			"zsh": {Perm: os.ModePerm, Children: map[string]fstest.File{
+				"zshrc": {Perm: 0o644, Data: []byte("zshrc")},
+			}},
+		}
+		if conf != "" {
+			files[conf] = fstest.File{Perm: 0o644, Data: []byte("targets: [zsh]")}
+		}
+		return files
+	}
+	testCases := []struct {
+		name     string
+		conf     string
+		env      string
+		cwd      []string
+		dotfiles map[string]fstest.File
+		pilgo    map[string]fstest.File
+		wantConf string
+		wantCwd  string
+	}{
+		{
+			name:     "set",
+			conf:     "foo.yml",
+			cwd:      []string{"home", "dotfiles", "zsh"},
+			dotfiles: dotfiles(config.DefaultName),
+			wantConf: "foo.yml",
+			wantCwd:  fstest.AbsPath("home", "dotfiles", "zsh"),
+		},
+		{
+			name:     "working directory",
+			cwd:      []string{"home", "dotfiles"},
+			dotfiles: dotfiles("pilgo.json"),
+			wantConf: "pilgo.json",
+			wantCwd:  fstest.AbsPath("home", "dotfiles"),
+		},
+		{
+			name:     "parent directory",
+			cwd:      []string{"home", "dotfiles", "zsh"},
+			dotfiles: dotfiles("pilgo.toml"),
+			wantConf: fstest.AbsPath("home", "dotfiles", "pilgo.toml"),
+			wantCwd:  fstest.AbsPath("home", "dotfiles"),
+		},
+		{
+			name:     "environment variable",
+			env:      filepath.Join("dotfiles", "foo.yml"),
+			cwd:      []string{"home"},
+			dotfiles: dotfiles("foo.yml"),
+			wantConf: fstest.AbsPath("home", "dotfiles", "foo.yml"),
+			wantCwd:  fstest.AbsPath("home", "dotfiles"),
+		},
+		{
+			name:     "environment variable symlink",
+			env:      filepath.Join("config", "pilgo", "config.yml"),
+			cwd:      []string{"home"},
+			dotfiles: dotfiles(config.DefaultName),
+			pilgo: map[string]fstest.File{
+				"config.yml": {Linkname: filepath.Join("..", "..", "dotfiles", config.DefaultName)},
+			},
+			wantConf: fstest.AbsPath("home", "dotfiles", config.DefaultName),
+			wantCwd:  fstest.AbsPath("home", "dotfiles"),
+		},
+		{
+			name:     "user configuration directory",
+			cwd:      []string{"home"},
+			dotfiles: dotfiles(config.DefaultName),
+			pilgo: map[string]fstest.File{
+				"config.yml": {Linkname: filepath.Join("..", "..", "dotfiles", config.DefaultName)},
+			},
+			wantConf: fstest.AbsPath("home", "dotfiles", config.DefaultName),
+			wantCwd:  fstest.AbsPath("home", "dotfiles"),
+		},
+		{
+			name:     "not found",
+			cwd:      []string{"home", "dotfiles", "zsh"},
+			dotfiles: dotfiles(""),
+			wantConf: config.DefaultName,
+			wantCwd:  fstest.AbsPath("home", "dotfiles", "zsh"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			confdir := map[string]fstest.File{}
+			if tc.pilgo != nil {
+				confdir["pilgo"] = fstest.File{Perm: os.ModePerm, Children: tc.pilgo}
+			}
+			var (
+				drv = fstest.InMemoryDriver{
+					Files: map[string]fstest.File{
+						"home": {
+							Perm: os.ModePerm,
+							Children: map[string]fstest.File{
+								"dotfiles": {Perm: os.ModePerm, Children: tc.dotfiles},
+								"config":   {Perm: os.ModePerm, Children: confdir},
+							},
+						},
+					},
+				}
+				appcfg = appConfig{
+					conf:          tc.conf,
+					fs:            &drv,
+					getenv:        func(string) string { return tc.env },
+					getwd:         func() (string, error) { return fstest.AbsPath(tc.cwd...), nil },
+					userConfigDir: func() (string, error) { return fstest.AbsPath("home", "config"), nil },
+				}
+				got = appcfg.discover()
+			)
+			if want, got := tc.wantConf, got.conf; got != want {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			cwd, err := got.getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, got := tc.wantCwd, cwd; got != want {
+				t.Errorf("want %q, got %q", want, got)
+			}
+		})
+	}
+}
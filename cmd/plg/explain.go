@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gbrlsnchs/cli"
+	"github.com/gbrlsnchs/cli/cliutil"
+	"github.com/gbrlsnchs/pilgo/parser"
+)
+
+var errUnknownTarget = errors.New("unknown target")
+
+type explainCmd struct {
+	target string
+	tags   cliutil.CommaSepOptionSet
+}
+
+func (cmd *explainCmd) register(getcfg func() appConfig) func(cli.Program) error {
+	return func(prg cli.Program) error {
+		appcfg := getcfg()
+		c, err := appcfg.readConfig(prg)
+		if err != nil {
+			return err
+		}
+		baseDirs, err := appcfg.baseDirs()
+		if err != nil {
+			return err
+		}
+		cwd, err := appcfg.getwd()
+		if err != nil {
+			return err
+		}
+		var p parser.Parser
+		tr, err := p.Parse(c,
+			parser.BaseDirs(baseDirs),
+			parser.Cwd(cwd),
+			parser.Envsubst,
+			parser.Tags(cmd.tags),
+			parser.Trace)
+		if err != nil {
+			return err
+		}
+		path := strings.Split(strings.Trim(filepath.ToSlash(cmd.target), "/"), "/")
+		n, excluded := findNode(tr.Root, path)
+		if n == nil {
+			return fmt.Errorf("%w %q", errUnknownTarget, cmd.target)
+		}
+		return fprintProvenance(prg.Stdout(), path, n, excluded)
+	}
+}
+
+// findNode returns the node whose target is path. If the target or one of its ancestors
+// is excluded by tags, the excluded node is returned instead and excluded is true.
+func findNode(n *parser.Node, path []string) (found *parser.Node, excluded bool) {
+	for _, name := range path {
+		next := childNamed(n.Children, name)
+		if next == nil {
+			return childNamed(n.Provenance.Excluded, name), true
+		}
+		n = next
+	}
+	return n, false
+}
+
+func childNamed(nodes []*parser.Node, name string) *parser.Node {
+	for _, n := range nodes {
+		if tg := n.Target.Path; tg[len(tg)-1] == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// fprintProvenance prints where each field that affects the link of n was set, which tags
+// it has and, finally, either its link or why it was excluded. If n is an excluded ancestor
+// of the target at path, it's also mentioned.
+func fprintProvenance(w io.Writer, path []string, n *parser.Node, excluded bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "target:\t%s\n", filepath.Join(n.Target.Path...))
+	var hasBaseDir bool
+	for _, f := range n.Provenance.Fields {
+		var from string
+		switch {
+		case !f.Set:
+			from = "default"
+		case len(f.Origin) == 0:
+			from = "set at the root"
+		case !f.Inherited(n.Target.Path):
+			from = "set for this target"
+		default:
+			from = "inherited from " + filepath.Join(f.Origin...)
+		}
+		switch f.Name {
+		case "baseDir":
+			hasBaseDir = f.Set
+		case "base", "useHome":
+			if hasBaseDir && f.Set {
+				from += ", overridden by baseDir"
+			}
+		}
+		value := f.Value
+		if value == "" {
+			value = `""`
+		}
+		fmt.Fprintf(tw, "%s:\t%s\t(%s)\n", f.Name, value, from)
+	}
+	tags := "none"
+	if len(n.Provenance.Tags) > 0 {
+		names := make([]string, len(n.Provenance.Tags))
+		for i, t := range n.Provenance.Tags {
+			state := "disabled"
+			if t.Enabled {
+				state = "enabled"
+			}
+			names[i] = fmt.Sprintf("%s (%s)", t.Name, state)
+		}
+		tags = strings.Join(names, ", ")
+	}
+	fmt.Fprintf(tw, "tags:\t%s\n", tags)
+	switch {
+	case !excluded:
+		fmt.Fprintf(tw, "result:\t%s\n", n.Link.FullPath())
+	case len(n.Target.Path) < len(path):
+		fmt.Fprintf(tw, "result:\texcluded, along with %s, since none of its tags are enabled\n",
+			filepath.Join(path...))
+	default:
+		fmt.Fprintf(tw, "result:\texcluded, since none of its tags are enabled\n")
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/gbrlsnchs/cli"
+	"github.com/gbrlsnchs/cli/clitest"
+	"github.com/gbrlsnchs/pilgo/config"
+	"github.com/gbrlsnchs/pilgo/fs/fstest"
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestFaults checks that commands fail cleanly when the file system does,
+// without leaving any changes behind.
+func TestFaults(t *testing.T) {
+	newDriver := func() fstest.InMemoryDriver {
+		return fstest.InMemoryDriver{
+			CurrentDir: "home/dotfiles",
+			Files: map[string]fstest.File{
+				"home": {
+					Perm: os.ModePerm,
+					Children: map[string]fstest.File{
+						"dotfiles": {
+							Perm: os.ModePerm,
+							Children: map[string]fstest.File{
+								"foo": {Perm: 0o644, Data: []byte("foo")},
+								"bar": {Perm: 0o644, Data: []byte("bar")},
+								config.DefaultName: {
+									Perm: 0o644,
+									Data: yamlData(config.Config{Targets: []string{"bar", "foo"}}),
+								},
+							},
+						},
+						"config": {Perm: os.ModePerm, Children: map[string]fstest.File{}},
+					},
+				},
+			},
+		}
+	}
+	everything := func(err error) []fstest.FaultRule {
+		methods := []string{
+			"Chmod", "Lchown", "MkdirAll", "ReadDir", "ReadFile", "Readlink",
+			"Remove", "RemoveAll", "Rename", "Stat", "StatFollow", "Symlink", "WriteFile",
+		}
+		rules := make([]fstest.FaultRule, len(methods))
+		for i, m := range methods {
+			rules[i] = fstest.FaultRule{Method: m, Err: err}
+		}
+		return rules
+	}
+	testCases := []struct {
+		name     string
+		register func(func() appConfig) func(cli.Program) error
+		rules    []fstest.FaultRule
+		err      error
+	}{
+		{
+			name:     "check read config",
+			register: execFunc(new(checkCmd).register),
+			rules:    []fstest.FaultRule{{Method: "ReadFile", Path: "*.yml", Err: syscall.EACCES}},
+			err:      syscall.EACCES,
+		},
+		{
+			name:     "check stat target",
+			register: execFunc(new(checkCmd).register),
+			rules:    []fstest.FaultRule{{Method: "Stat", Path: "foo", Err: syscall.EIO}},
+			err:      syscall.EIO,
+		},
+		{
+			name:     "config write",
+			register: (&configCmd{file: "foo", link: "bar"}).register,
+			rules:    []fstest.FaultRule{{Method: "WriteFile", Path: "*.yml", Err: syscall.ENOSPC}},
+			err:      syscall.ENOSPC,
+		},
+		{
+			name:     "config stat",
+			register: (&configCmd{file: "foo", link: "bar"}).register,
+			rules:    []fstest.FaultRule{{Method: "Stat", Path: "*.yml", Err: syscall.EACCES}},
+			err:      syscall.EACCES,
+		},
+		{
+			name:     "explain read config",
+			register: (&explainCmd{target: "foo"}).register,
+			rules:    []fstest.FaultRule{{Method: "ReadFile", Path: "*.yml", Err: syscall.EACCES}},
+			err:      syscall.EACCES,
+		},
+		{
+			name:     "init read dir",
+			register: (&initCmd{force: true}).register,
+			rules:    []fstest.FaultRule{{Method: "ReadDir", Err: syscall.EACCES}},
+			err:      syscall.EACCES,
+		},
+		{
+			name:     "init write",
+			register: (&initCmd{force: true}).register,
+			rules:    []fstest.FaultRule{{Method: "WriteFile", Path: "*.yml", Err: syscall.ENOSPC}},
+			err:      syscall.ENOSPC,
+		},
+		{
+			name:     "link first symlink",
+			register: new(linkCmd).register,
+			rules:    []fstest.FaultRule{{Method: "Symlink", N: 1, Err: syscall.EACCES}},
+			err:      syscall.EACCES,
+		},
+		{
+			name:     "link second symlink",
+			register: new(linkCmd).register,
+			rules:    []fstest.FaultRule{{Method: "Symlink", N: 2, Err: syscall.ENOSPC}},
+			err:      syscall.ENOSPC,
+		},
+		{
+			name:     "link mkdir",
+			register: new(linkCmd).register,
+			rules:    []fstest.FaultRule{{Method: "MkdirAll", Err: syscall.EROFS}},
+			err:      syscall.EROFS,
+		},
+		{
+			name:     "scan read dir",
+			register: execFunc(new(scanCmd).register),
+			rules:    []fstest.FaultRule{{Method: "ReadDir", Err: syscall.EACCES}},
+			err:      syscall.EACCES,
+		},
+		{
+			name:     "scan write",
+			register: execFunc(new(scanCmd).register),
+			rules:    []fstest.FaultRule{{Method: "WriteFile", Path: "*.yml", Err: syscall.ENOSPC}},
+			err:      syscall.ENOSPC,
+		},
+		{
+			name:     "show read config",
+			register: new(showCmd).register,
+			rules:    []fstest.FaultRule{{Method: "ReadFile", Path: "*.yml", Err: syscall.EIO}},
+			err:      syscall.EIO,
+		},
+		{
+			name:     "validate read config",
+			register: new(validateCmd).register,
+			rules:    []fstest.FaultRule{{Method: "ReadFile", Path: "*.yml", Err: syscall.EIO}},
+			err:      syscall.EIO,
+		},
+		{
+			name:     "validate schema",
+			register: (&validateCmd{schema: true}).register,
+			rules:    everything(syscall.EIO),
+			err:      nil,
+		},
+		{
+			name:     "version",
+			register: new(versionCmd).register,
+			rules:    everything(syscall.EIO),
+			err:      nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				drv    = newDriver()
+				appcfg = appConfig{
+					conf:          config.DefaultName,
+					fs:            &fstest.FaultDriver{Driver: &drv, Rules: tc.rules},
+					getenv:        func(string) string { return "" },
+					getwd:         func() (string, error) { return fstest.AbsPath("home", "dotfiles"), nil },
+					userConfigDir: func() (string, error) { return fstest.AbsPath("home", "config"), nil },
+					userHomeDir:   func() (string, error) { return fstest.AbsPath("home"), nil },
+				}
+				exec = tc.register(appcfg.copy)
+				err  = exec(clitest.NewProgram(tc.name))
+			)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if want, got := newDriver(), drv; !cmp.Equal(got, want) {
+				t.Fatalf("command has unintended effects in the file system: (-want +got):\n%s",
+					cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+// execFunc adapts register methods that return cli.ExecFunc.
+func execFunc(register func(func() appConfig) cli.ExecFunc) func(func() appConfig) func(cli.Program) error {
+	return func(getcfg func() appConfig) func(cli.Program) error { return register(getcfg) }
+}
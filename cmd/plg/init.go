@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"os"
 
 	"github.com/gbrlsnchs/cli"
@@ -9,11 +10,15 @@ import (
 	"github.com/gbrlsnchs/pilgo/fs"
 )
 
-var errConfigExists = errors.New("configuration file already exists")
+var (
+	errConfigExists   = errors.New("configuration file already exists")
+	errFormatMismatch = errors.New("format doesn't match the configuration file's extension")
+)
 
 type initCmd struct {
-	force bool
-	read  readMode
+	force  bool
+	format string
+	read   readMode
 }
 
 func (cmd *initCmd) register(getcfg func() appConfig) func(cli.Program) error {
@@ -23,6 +28,18 @@ func (cmd *initCmd) register(getcfg func() appConfig) func(cli.Program) error {
 			fs     = fs.New(appcfg.fs)
 		)
 		conf := appcfg.conf
+		if cmd.format != "" {
+			name, err := config.NameFor(cmd.format)
+			if err != nil {
+				return err
+			}
+			switch {
+			case conf == config.DefaultName:
+				conf = name
+			case config.CodecFor(conf) != config.CodecFor(name):
+				return fmt.Errorf("%w %q", errFormatMismatch, conf)
+			}
+		}
 		fi, err := fs.Stat(conf)
 		if err != nil {
 			return err
@@ -46,7 +63,7 @@ func (cmd *initCmd) register(getcfg func() appConfig) func(cli.Program) error {
 			perm = fi.Perm()
 		}
 		c := &config.Config{Targets: targets}
-		b, err := marshalYAML(c)
+		b, err := config.Encode(conf, c)
 		if err != nil {
 			return err
 		}
@@ -2,18 +2,16 @@ package main
 
 import (
 	"errors"
-	"fmt"
 
 	"github.com/gbrlsnchs/cli"
 	"github.com/gbrlsnchs/cli/cliutil"
-	"github.com/gbrlsnchs/pilgo/config"
 	"github.com/gbrlsnchs/pilgo/fs"
 	"github.com/gbrlsnchs/pilgo/linker"
 	"github.com/gbrlsnchs/pilgo/parser"
-	"gopkg.in/yaml.v3"
 )
 
-type linkCmd struct{
+type linkCmd struct {
+	jobs int
 	tags cliutil.CommaSepOptionSet
 }
 
@@ -26,42 +24,35 @@ func (cmd *linkCmd) register(getcfg func() appConfig) func(cli.Program) error {
 		if err != nil {
 			return err
 		}
-		b, err := fs.ReadFile(appcfg.conf)
+		c, err := appcfg.readConfig(prg)
 		if err != nil {
 			return err
 		}
-		c := new(config.Config)
-		if yaml.Unmarshal(b, c); err != nil {
-			return err
-		}
-		userConfigDir, err := appcfg.userConfigDir()
-		if err != nil {
-			return err
-		}
-		homeConfigDir, err := appcfg.userHomeDir()
+		baseDirs, err := appcfg.baseDirs()
 		if err != nil {
 			return err
 		}
 		var p parser.Parser
 		tr, err := p.Parse(c,
-			parser.BaseDirs(map[parser.Mode]string{
-				parser.UserMode: userConfigDir,
-				parser.HomeMode: homeConfigDir,
-			}),
+			parser.BaseDirs(baseDirs),
 			parser.Cwd(cwd),
 			parser.Envsubst,
 			parser.Tags(cmd.tags))
 		if err != nil {
 			return err
 		}
-		ln := linker.New(fs)
+		if err := tr.Validate(); err != nil {
+			var cerr *parser.CollisionError
+			if errors.As(err, &cerr) {
+				fprintCollisions(prg.Stderr(), exe, cerr)
+			}
+			return err
+		}
+		ln := linker.New(fs, concurrency(cmd.jobs))
 		if err := ln.Link(tr); err != nil {
 			var cft *linker.ConflictError
 			if errors.As(err, &cft) {
-				errw := prg.Stderr()
-				for _, err := range cft.Errs {
-					fmt.Fprintf(errw, "%s: %v\n", exe, err)
-				}
+				fprintConflicts(prg.Stderr(), exe, cft)
 			}
 			return err
 		}
@@ -1,15 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gbrlsnchs/cli/clitest"
 	"github.com/gbrlsnchs/cli/cliutil"
 	"github.com/gbrlsnchs/pilgo/config"
 	"github.com/gbrlsnchs/pilgo/fs/fstest"
+	"github.com/gbrlsnchs/pilgo/fs/fsutil"
 	"github.com/gbrlsnchs/pilgo/linker"
 	"github.com/google/go-cmp/cmp"
 )
@@ -119,13 +124,13 @@ func TestLink(t *testing.T) {
 								Data:     nil,
 								Children: map[string]fstest.File{
 									"test": {
-										Linkname: filepath.Join("home", "dotfiles", "test"),
+										Linkname: fstest.AbsPath("home", "dotfiles", "test"),
 										Data:     nil,
 										Perm:     os.ModePerm,
 										Children: nil,
 									},
 									"link.txt": {
-										Linkname: filepath.Join("home", "dotfiles", "link.txt"),
+										Linkname: fstest.AbsPath("home", "dotfiles", "link.txt"),
 										Data:     nil,
 										Perm:     os.ModePerm,
 										Children: nil,
@@ -165,7 +170,7 @@ func TestLink(t *testing.T) {
 										Data:     []byte("bar"),
 										Children: nil,
 									},
-									"default.yml": {
+									"conflict.yml": {
 										Linkname: "",
 										Perm:     os.ModePerm,
 										Data: yamlData(config.Config{
@@ -221,7 +226,7 @@ func TestLink(t *testing.T) {
 										Data:     []byte("bar"),
 										Children: nil,
 									},
-									"default.yml": {
+									"conflict.yml": {
 										Linkname: "",
 										Perm:     os.ModePerm,
 										Data: yamlData(config.Config{
@@ -358,7 +363,7 @@ func TestLink(t *testing.T) {
 								Data:     nil,
 								Children: map[string]fstest.File{
 									"link.txt": {
-										Linkname: filepath.Join("home", "dotfiles", "link.txt"),
+										Linkname: fstest.AbsPath("home", "dotfiles", "link.txt"),
 										Data:     nil,
 										Perm:     os.ModePerm,
 										Children: nil,
@@ -480,13 +485,13 @@ func TestLink(t *testing.T) {
 								Data:     nil,
 								Children: map[string]fstest.File{
 									"test": {
-										Linkname: filepath.Join("home", "dotfiles", "test"),
+										Linkname: fstest.AbsPath("home", "dotfiles", "test"),
 										Data:     nil,
 										Perm:     os.ModePerm,
 										Children: nil,
 									},
 									"link.txt": {
-										Linkname: filepath.Join("home", "dotfiles", "link.txt"),
+										Linkname: fstest.AbsPath("home", "dotfiles", "link.txt"),
 										Data:     nil,
 										Perm:     os.ModePerm,
 										Children: nil,
@@ -506,6 +511,7 @@ func TestLink(t *testing.T) {
 				appcfg = appConfig{
 					conf:          filepath.Base(t.Name()) + ".yml",
 					fs:            &tc.drv,
+					getenv:        func(string) string { return "" },
 					getwd:         func() (string, error) { return fstest.AbsPath("home", "dotfiles"), nil },
 					userConfigDir: func() (string, error) { return fstest.AbsPath("home", "config"), nil },
 					userHomeDir:   func() (string, error) { return fstest.AbsPath("home"), nil },
@@ -513,8 +519,9 @@ func TestLink(t *testing.T) {
 				exec = tc.cmd.register(appcfg.copy)
 				prg  = clitest.NewProgram("link")
 				err  = exec(prg)
+				cft  *linker.ConflictError
 			)
-			if !errors.As(err, &tc.err) {
+			if !errors.As(err, &cft) {
 				if want, got := tc.err, err; !errors.Is(got, want) {
 					t.Fatalf("want %v, got %v", want, got)
 				}
@@ -530,3 +537,95 @@ func TestLink(t *testing.T) {
 		})
 	}
 }
+
+func TestLinkAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pilgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var (
+		drv = fstest.InMemoryDriver{
+			CurrentDir: "home/dotfiles",
+			Files: map[string]fstest.File{
+				"home": {
+					Perm: os.ModePerm,
+					Children: map[string]fstest.File{
+						"dotfiles": {
+							Perm: os.ModePerm,
+							Children: map[string]fstest.File{
+								"test": {Perm: os.ModePerm, Data: []byte("foo")},
+								config.DefaultName: {
+									Perm: os.ModePerm,
+									Data: yamlData(config.Config{Targets: []string{"test"}}),
+								},
+							},
+						},
+						"config": {Perm: os.ModePerm, Children: map[string]fstest.File{}},
+					},
+				},
+			},
+		}
+		auditLog = filepath.Join(dir, "audit.log")
+		appcfg   = appConfig{
+			auditLog:      auditLog,
+			conf:          config.DefaultName,
+			fs:            &drv,
+			getenv:        func(string) string { return "" },
+			getwd:         func() (string, error) { return fstest.AbsPath("home", "dotfiles"), nil },
+			userConfigDir: func() (string, error) { return fstest.AbsPath("home", "config"), nil },
+			userHomeDir:   func() (string, error) { return fstest.AbsPath("home"), nil },
+		}
+		cmd  linkCmd
+		exec = cmd.register(appcfg.copy)
+	)
+	if err := exec(clitest.NewProgram("link")); err != nil {
+		t.Fatal(err)
+	}
+	// The audit log is kept open between records.
+	if appcfg.audit == nil || appcfg.audit.f == nil {
+		t.Fatal("want the audit log to be open")
+	}
+	if err := appcfg.close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(auditLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []fsutil.Record
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for dec.More() {
+		var r fsutil.Record
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Time.IsZero() {
+			t.Errorf("missing time for %q", r.Op)
+		}
+		r.Time = time.Time{}
+		got = append(got, r)
+	}
+	want := []fsutil.Record{{
+		Op:   "mkdirall",
+		Name: fstest.AbsPath("home", "config"),
+	}, {
+		Op:   "mkdirall",
+		Name: fstest.AbsPath("home", "config"),
+		Done: true,
+		OK:   true,
+	}, {
+		Op:      "symlink",
+		Oldname: fstest.AbsPath("home", "dotfiles", "test"),
+		Newname: fstest.AbsPath("home", "config", "test"),
+	}, {
+		Op:      "symlink",
+		Oldname: fstest.AbsPath("home", "dotfiles", "test"),
+		Newname: fstest.AbsPath("home", "config", "test"),
+		Done:    true,
+		OK:      true,
+	}}
+	if !cmp.Equal(got, want) {
+		t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/gbrlsnchs/cli"
 	"github.com/gbrlsnchs/pilgo/cmd/internal"
@@ -12,25 +14,59 @@ import (
 
 type appConfig struct {
 	name          string
+	audit         *appendFile
+	auditLog      string
 	conf          string
 	fs            fs.Driver
+	getenv        func(string) string
 	getwd         func() (string, error)
+	root          string
 	userConfigDir func() (string, error)
 	userHomeDir   func() (string, error)
+	verbose       bool
 	version       string
 }
 
-func (cfg *appConfig) copy() appConfig { return *cfg }
+func (cfg *appConfig) copy() appConfig {
+	c := *cfg
+	if c.conf == "" {
+		c.conf = config.DefaultName
+	}
+	if c.root != "" {
+		drv := fsutil.RootedDriver{Root: c.root}
+		c.fs = drv
+		c.getwd = drv.Getwd
+	}
+	if c.auditLog != "" {
+		// The audit log is kept open until the program exits.
+		if cfg.audit == nil {
+			cfg.audit = &appendFile{name: c.auditLog}
+		}
+		c.audit = cfg.audit
+		c.fs = &fsutil.RecordingDriver{Driver: c.fs, Writer: c.audit}
+	}
+	return c
+}
+
+// close releases what copies of cfg have opened, i.e. the audit log.
+func (cfg *appConfig) close() error {
+	if cfg.audit == nil {
+		return nil
+	}
+	return cfg.audit.Close()
+}
 
 type rootCmd struct {
 	// store
-	check   checkCmd
-	config  configCmd
-	init    initCmd
-	link    linkCmd
-	scan    scanCmd
-	show    showCmd
-	version versionCmd
+	check    checkCmd
+	config   configCmd
+	explain  explainCmd
+	init     initCmd
+	link     linkCmd
+	scan     scanCmd
+	show     showCmd
+	validate validateCmd
+	version  versionCmd
 }
 
 func main() {
@@ -43,6 +79,7 @@ func run() int {
 		appcfg = appConfig{
 			name:          "Pilgo",
 			fs:            fsutil.OSDriver{},
+			getenv:        os.Getenv,
 			getwd:         os.Getwd,
 			userConfigDir: os.UserConfigDir,
 			userHomeDir:   os.UserHomeDir,
@@ -51,27 +88,101 @@ func run() int {
 	)
 	cli := cli.New(&cli.Command{
 		Options: map[string]cli.Option{
+			"audit-log": cli.StringOption{
+				OptionDetails: cli.OptionDetails{
+					Description: "Append a record of every change made to files to FILE, in JSON lines.",
+					ArgLabel:    "FILE",
+				},
+				Recipient: &appcfg.auditLog,
+			},
 			"config": cli.StringOption{
 				OptionDetails: cli.OptionDetails{
-					Description: "Use a different configuration file.",
+					Description: "Use a different configuration file. By default, it is looked up in PILGO_CONFIG, in the current directory and its parents, and then in the user configuration directory.",
 					Short:       'c',
 				},
-				DefValue:  config.DefaultName,
 				Recipient: &appcfg.conf,
 			},
+			"root": cli.StringOption{
+				OptionDetails: cli.OptionDetails{
+					Description: "Confine all file operations to DIR, as if it were the root directory.",
+					ArgLabel:    "DIR",
+				},
+				Recipient: &appcfg.root,
+			},
+			"verbose": cli.BoolOption{
+				OptionDetails: cli.OptionDetails{
+					Description: "Print which configuration file is used.",
+					Short:       'v',
+				},
+				Recipient: &appcfg.verbose,
+			},
 		},
 		Subcommands: map[string]*cli.Command{
 			"check": {
 				Description: "Check the status of your dotfiles.",
 				Options: map[string]cli.Option{
-					"fail": cli.BoolOption{
+					"charset": cli.StringOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Use a different charset for drawing the tree. Available charsets are \"unicode\" and \"ascii\". It can also be set with PILGO_CHARSET.",
+							ArgLabel:    "CHARSET",
+						},
+						Recipient: &root.check.print.charset,
+					},
+					"collapse-done": cli.BoolOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Fold directories whose files are all already symlinked into a single line.",
+						},
+						Recipient: &root.check.print.collapseDone,
+					},
+					"depth": cli.IntOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Only print the tree down to N levels deep. Omitted files are counted instead.",
+							ArgLabel:    "N",
+						},
+						Recipient: &root.check.print.depth,
+					},
+					"fail": cli.VarOption{
 						OptionDetails: cli.OptionDetails{
 							Short:       'f',
-							Description: "Return an error if there are any conflicts.",
+							Description: "Return an error if there are any conflicts. Use -fail=ready to also return an error if there are missing links.",
 						},
-						DefValue:  false,
 						Recipient: &root.check.fail,
 					},
+					"format": cli.VarOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Print the tree in a different format. Available formats are \"tree\", \"dot\" and \"mermaid\".",
+							ArgLabel:    "FORMAT",
+						},
+						Recipient: &root.check.print.format,
+					},
+					"jobs": cli.IntOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Resolve up to N targets at the same time. Defaults to the number of CPUs.",
+							ArgLabel:    "N",
+						},
+						Recipient: &root.check.jobs,
+					},
+					"path": cli.StringOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Only print targets whose path starts with PATH.",
+							Short:       'p',
+							ArgLabel:    "PATH",
+						},
+						Recipient: &root.check.print.path,
+					},
+					"status": cli.VarOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Comma-separated list of statuses. Only targets with these statuses will be printed.",
+							ArgLabel:    "STATUS 1,...,STATUS n",
+						},
+						Recipient: &root.check.print.status,
+					},
+					"summary": cli.BoolOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Print only how many links are in each status.",
+						},
+						Recipient: &root.check.summary,
+					},
 					"tags": cli.VarOption{
 						OptionDetails: cli.OptionDetails{
 							Description: "Comma-separated list of tags. Targets with these tags will also be checked.",
@@ -81,7 +192,7 @@ func run() int {
 						Recipient: &root.check.tags,
 					},
 				},
-				Exec: root.check.register(appcfg.copy),
+				Exec: root.check.register(appcfg.discover),
 			},
 			"config": {
 				Description: "Configure a dotfile in the configuration file.",
@@ -94,6 +205,13 @@ func run() int {
 						},
 						Recipient: &root.config.baseDir,
 					},
+					"base": cli.StringOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Set the target's base directory by name. Available names are \"config\", \"home\", \"data\", \"state\", \"cache\", \"bin\" and aliases set in \"baseDirs\". Works recursively for all nested targets, unless overridden.",
+							ArgLabel:    "NAME",
+						},
+						Recipient: &root.config.base,
+					},
 					"link": cli.StringOption{
 						OptionDetails: cli.OptionDetails{
 							Description: "Set the target's link name.",
@@ -104,7 +222,7 @@ func run() int {
 					},
 					"usehome": cli.VarOption{
 						OptionDetails: cli.OptionDetails{
-							Description: "Use home directory as the target's base directory and recursively for all nested targets, unless overridden.",
+							Description: "Use home directory as the target's base directory and recursively for all nested targets, unless overridden. Same as \"-base home\".",
 							Short:       'H',
 						},
 						Recipient: &root.config.useHome,
@@ -130,7 +248,26 @@ func run() int {
 					Required:  false,
 					Recipient: &root.config.file,
 				},
-				Exec: root.config.register(appcfg.copy),
+				Exec: root.config.register(appcfg.discover),
+			},
+			"explain": {
+				Description: "Explain how a target's link is resolved or why it is excluded.",
+				Options: map[string]cli.Option{
+					"tags": cli.VarOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Comma-separated list of tags. Targets with these tags will also be included.",
+							Short:       't',
+							ArgLabel:    "TAG 1,...,TAG n",
+						},
+						Recipient: &root.explain.tags,
+					},
+				},
+				Arg: cli.StringArg{
+					Label:     "TARGET",
+					Required:  true,
+					Recipient: &root.explain.target,
+				},
+				Exec: root.explain.register(appcfg.discover),
 			},
 			"init": {
 				Description: "Initialize a configuration file.",
@@ -142,6 +279,13 @@ func run() int {
 						},
 						Recipient: &root.init.force,
 					},
+					"format": cli.StringOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Use a different format for the configuration file. Available formats are \"yaml\", \"toml\" and \"json\". Unless a configuration file is set, it is named after the format, e.g. \"pilgo.toml\".",
+							ArgLabel:    "FORMAT",
+						},
+						Recipient: &root.init.format,
+					},
 					"include": cli.VarOption{
 						OptionDetails: cli.OptionDetails{
 							Description: "File to be exclusively included as a target. Repeat option to include more files.",
@@ -168,8 +312,15 @@ func run() int {
 			},
 			"link": {
 				Description: "Link your dotfiles as set in the configuration file.",
-				Exec:        root.link.register(appcfg.copy),
+				Exec:        root.link.register(appcfg.discover),
 				Options: map[string]cli.Option{
+					"jobs": cli.IntOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Resolve up to N targets at the same time. Defaults to the number of CPUs.",
+							ArgLabel:    "N",
+						},
+						Recipient: &root.link.jobs,
+					},
 					"tags": cli.VarOption{
 						OptionDetails: cli.OptionDetails{
 							Description: "Comma-separated list of tags. Targets with these tags will also be linked.",
@@ -210,12 +361,41 @@ func run() int {
 					Required:  false,
 					Recipient: &root.scan.file,
 				},
-				Exec: root.scan.register(appcfg.copy),
+				Exec: root.scan.register(appcfg.discover),
 			},
 			"show": {
 				Description: "Show your dotfiles in a tree view.",
-				Exec:        root.show.register(appcfg.copy),
+				Exec:        root.show.register(appcfg.discover),
 				Options: map[string]cli.Option{
+					"charset": cli.StringOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Use a different charset for drawing the tree. Available charsets are \"unicode\" and \"ascii\". It can also be set with PILGO_CHARSET.",
+							ArgLabel:    "CHARSET",
+						},
+						Recipient: &root.show.print.charset,
+					},
+					"depth": cli.IntOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Only print the tree down to N levels deep. Omitted files are counted instead.",
+							ArgLabel:    "N",
+						},
+						Recipient: &root.show.print.depth,
+					},
+					"format": cli.VarOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Print the tree in a different format. Available formats are \"tree\", \"dot\" and \"mermaid\".",
+							ArgLabel:    "FORMAT",
+						},
+						Recipient: &root.show.print.format,
+					},
+					"path": cli.StringOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Only print targets whose path starts with PATH.",
+							Short:       'p',
+							ArgLabel:    "PATH",
+						},
+						Recipient: &root.show.print.path,
+					},
 					"tags": cli.VarOption{
 						OptionDetails: cli.OptionDetails{
 							Description: "Comma-separated list of tags. Targets with these tags will also be shown.",
@@ -224,6 +404,32 @@ func run() int {
 						},
 						Recipient: &root.show.tags,
 					},
+					"vars": cli.BoolOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Print the variables set in the configuration for each target instead of the tree, including inherited ones.",
+						},
+						Recipient: &root.show.vars,
+					},
+				},
+			},
+			"validate": {
+				Description: "Validate the configuration file.",
+				Exec:        root.validate.register(appcfg.discover),
+				Options: map[string]cli.Option{
+					"schema": cli.BoolOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Print a JSON Schema for configuration files instead.",
+						},
+						Recipient: &root.validate.schema,
+					},
+					"tags": cli.VarOption{
+						OptionDetails: cli.OptionDetails{
+							Description: "Comma-separated list of tags. Targets with these tags will also be validated.",
+							Short:       't',
+							ArgLabel:    "TAG 1,...,TAG n",
+						},
+						Recipient: &root.validate.tags,
+					},
 				},
 			},
 			"version": {
@@ -232,5 +438,12 @@ func run() int {
 			},
 		},
 	})
-	return cli.ParseAndRun(os.Args)
+	code := cli.ParseAndRun(os.Args)
+	if err := appcfg.close(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", filepath.Base(os.Args[0]), err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
 }
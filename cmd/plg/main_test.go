@@ -25,6 +25,10 @@ func TestCLI(t *testing.T) {
 		t.Fatal(err)
 	}
 	ts.Setup = func(rootdir string) error {
+		// Don't let the environment affect how configuration files are found
+		// or how trees are drawn.
+		os.Unsetenv(configEnv)
+		os.Unsetenv(charsetEnv)
 		if runtime.GOOS == "darwin" {
 			// XXX: Fix "/private${ROOTDIR}" being printed when on macOS.
 			// This way, it is possible to unify Unix tests.
@@ -1,17 +1,105 @@
 package main
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 
-	"gopkg.in/yaml.v3"
+	"github.com/gbrlsnchs/cli"
+	"github.com/gbrlsnchs/pilgo/config"
+	"github.com/gbrlsnchs/pilgo/fs"
+	"github.com/gbrlsnchs/pilgo/linker"
+	"github.com/gbrlsnchs/pilgo/parser"
 )
 
-func marshalYAML(v interface{}) ([]byte, error) {
-	var buf bytes.Buffer
-	enc := yaml.NewEncoder(&buf)
-	enc.SetIndent(2)
-	if err := enc.Encode(v); err != nil {
+// readConfig reads and strictly decodes the configuration file. If the file is invalid,
+// every problem found is printed to the program's standard error.
+func (cfg appConfig) readConfig(prg cli.Program) (*config.Config, error) {
+	if cfg.verbose {
+		fmt.Fprintf(prg.Stderr(), "%s: using %s\n", prg.Name(), cfg.conf)
+	}
+	b, err := fs.New(cfg.fs).ReadFile(cfg.conf)
+	if err != nil {
+		return nil, err
+	}
+	c := new(config.Config)
+	if err := config.Decode(cfg.conf, b, c); err != nil {
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			errw := prg.Stderr()
+			for _, err := range verr.Errs {
+				fmt.Fprintf(errw, "%s: %v\n", prg.Name(), err)
+			}
+		}
 		return nil, err
 	}
-	return buf.Bytes(), nil
+	return c, nil
+}
+
+// baseDirs returns the directory of every parser mode. Except for the configuration
+// directory, which depends on the platform, they follow the XDG Base Directory Specification.
+func (cfg appConfig) baseDirs() (map[parser.Mode]string, error) {
+	configDir, err := cfg.userConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := cfg.userHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	xdgDir := func(env string, elems ...string) string {
+		// Relative paths are invalid and thus ignored.
+		if dir := cfg.getenv(env); filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(append([]string{homeDir}, elems...)...)
+	}
+	return map[parser.Mode]string{
+		parser.UserMode:  configDir,
+		parser.HomeMode:  homeDir,
+		parser.DataMode:  xdgDir("XDG_DATA_HOME", ".local", "share"),
+		parser.StateMode: xdgDir("XDG_STATE_HOME", ".local", "state"),
+		parser.CacheMode: xdgDir("XDG_CACHE_HOME", ".cache"),
+		parser.BinMode:   filepath.Join(homeDir, ".local", "bin"),
+	}, nil
+}
+
+// concurrency returns a linker option for resolving up to jobs targets at the same time.
+// Unless jobs is positive, it defaults to the number of CPUs.
+func concurrency(jobs int) linker.Option {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	return linker.Concurrency(jobs)
+}
+
+// appendFile is a writer that appends to a file, which is only created
+// when something is written to it. The file is then kept open until it's closed.
+// It's not safe for concurrent use, which RecordingDriver already prevents.
+type appendFile struct {
+	name string
+	f    *os.File
+}
+
+func (af *appendFile) Write(b []byte) (int, error) {
+	if af.f == nil {
+		f, err := os.OpenFile(af.name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return 0, err
+		}
+		af.f = f
+	}
+	return af.f.Write(b)
+}
+
+// Close closes the file, if it has been opened at all.
+func (af *appendFile) Close() error {
+	if af.f == nil {
+		return nil
+	}
+	err := af.f.Close()
+	af.f = nil
+	return err
 }
@@ -2,9 +2,11 @@ package main
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"github.com/andybalholm/crlf"
+	"github.com/gbrlsnchs/pilgo/config"
 	"golang.org/x/text/transform"
 )
 
@@ -23,8 +25,20 @@ func readFile(name string) ([]byte, error) {
 	return goldenlf, nil
 }
 
-func yamlData(v interface{}) []byte {
-	b, err := marshalYAML(v)
+// writeGolden updates a golden file. Empty outputs are represented
+// by the absence of their respective golden files.
+func writeGolden(name, content string) error {
+	if content == "" {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return ioutil.WriteFile(name, []byte(filepath.ToSlash(content)), 0o644)
+}
+
+func yamlData(c config.Config) []byte {
+	b, err := config.YAML.Marshal(&c)
 	if err != nil {
 		panic(err)
 	}
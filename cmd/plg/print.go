@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gbrlsnchs/pilgo/linker"
+	"github.com/gbrlsnchs/pilgo/parser"
+)
+
+const charsetEnv = "PILGO_CHARSET"
+
+type printMode struct {
+	path    string
+	status  statusSet
+	format  formatName
+	charset string
+	depth   int
+	// collapseDone folds subtrees whose files are all done.
+	collapseDone bool
+}
+
+// fprint prints tr to w. Unless a charset is set, it's read with getenv.
+func (md *printMode) fprint(w io.Writer, tr *parser.Tree, getenv func(string) string) error {
+	opts := []parser.PrintOption{
+		parser.OutputFormat(parser.Format(md.format)),
+		parser.MaxDepth(md.depth),
+	}
+	if md.collapseDone {
+		opts = append(opts, parser.CollapseDone)
+	}
+	charset := md.charset
+	if charset == "" {
+		charset = getenv(charsetEnv)
+	}
+	if charset != "" {
+		cs, err := parser.ParseCharset(charset)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, parser.TreeCharset(cs))
+	}
+	return md.filter(tr).Fprint(w, opts...)
+}
+
+func (md *printMode) isTree() bool { return parser.Format(md.format) == parser.FormatTree }
+
+func (md *printMode) filter(tr *parser.Tree) *parser.Tree {
+	if md.path == "" && md.status == 0 {
+		return tr
+	}
+	prefix := filepath.ToSlash(md.path)
+	return tr.Filter(func(n *parser.Node) bool {
+		if md.status != 0 && parser.Status(md.status)&n.Status == 0 {
+			return false
+		}
+		// Trailing slashes allow matching directories exclusively.
+		tgpath := strings.Join(n.Target.Path, "/") + "/"
+		return strings.HasPrefix(tgpath, prefix)
+	})
+}
+
+type statusSet parser.Status
+
+func (set *statusSet) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		st, err := parser.ParseStatus(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		*set |= statusSet(st)
+	}
+	return nil
+}
+
+func (set *statusSet) String() string {
+	var names []string
+	for st := parser.StatusReady; st != 0 && st <= parser.Status(*set); st <<= 1 {
+		if parser.Status(*set)&st != 0 {
+			names = append(names, strings.ToLower(st.String()))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+type formatName parser.Format
+
+func (f *formatName) Set(value string) error {
+	format, err := parser.ParseFormat(value)
+	if err != nil {
+		return err
+	}
+	*f = formatName(format)
+	return nil
+}
+
+func (f *formatName) String() string { return parser.Format(*f).String() }
+
+// fprintConflicts prints conflicts grouped by kind, in order of appearance,
+// each group followed by a hint on how to fix them.
+func fprintConflicts(w io.Writer, name string, cft *linker.ConflictError) {
+	var (
+		kinds  []linker.ConflictKind
+		groups = make(map[linker.ConflictKind][]*linker.Conflict)
+	)
+	for _, err := range cft.Errs {
+		var c *linker.Conflict
+		if !errors.As(err, &c) {
+			fmt.Fprintf(w, "%s: %v\n", name, err)
+			continue
+		}
+		if _, ok := groups[c.Kind]; !ok {
+			kinds = append(kinds, c.Kind)
+		}
+		groups[c.Kind] = append(groups[c.Kind], c)
+	}
+	for _, k := range kinds {
+		for _, c := range groups[k] {
+			fmt.Fprintf(w, "%s: %v\n", name, c)
+		}
+		fmt.Fprintf(w, "%s: hint: %s\n", name, groups[k][0].Hint())
+	}
+}
+
+// fprintCollisions prints link collisions followed by a hint on how to fix them.
+func fprintCollisions(w io.Writer, name string, cerr *parser.CollisionError) {
+	for _, c := range cerr.Collisions {
+		fmt.Fprintf(w, "%s: %v\n", name, c)
+	}
+	fmt.Fprintf(w, "%s: hint: %s\n", name,
+		`give targets distinct link names with "plg config -link", or don't flatten them`)
+}
+
+// fprintVars prints the variables of every node that has any, including the root node.
+func fprintVars(w io.Writer, tr *parser.Tree) error {
+	var b strings.Builder
+	fn := func(n *parser.Node) error {
+		if len(n.Vars) == 0 {
+			return nil
+		}
+		name := "."
+		if len(n.Target.Path) > 0 {
+			name = filepath.Join(n.Target.Path...)
+		}
+		b.WriteString(name + "\n")
+		keys := make([]string, 0, len(n.Vars))
+		for k := range n.Vars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "    %s=%s\n", k, n.Vars[k])
+		}
+		return nil
+	}
+	fn(tr.Root)
+	tr.Walk(fn)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -1,9 +1,10 @@
 package main
 
 import (
+	"path/filepath"
+
 	"github.com/gbrlsnchs/cli"
 	"github.com/gbrlsnchs/pilgo/config"
-	"gopkg.in/yaml.v3"
 )
 
 type scanCmd struct {
@@ -12,27 +13,30 @@ type scanCmd struct {
 }
 
 func (cmd *scanCmd) register(getcfg func() appConfig) cli.ExecFunc {
-	return func(_ cli.Program) error {
+	return func(prg cli.Program) error {
 		appcfg := getcfg()
 		fs := appcfg.fs
 		conf := appcfg.conf
-		b, err := fs.ReadFile(conf)
+		c, err := appcfg.readConfig(prg)
 		if err != nil {
 			return err
 		}
-		files, err := fs.ReadDir(cmd.file)
+		// Targets are relative to the configuration file, which
+		// might have been found in a parent directory.
+		dirname := cmd.file
+		if dir := filepath.Dir(conf); dir != "." {
+			dirname = filepath.Join(dir, dirname)
+		}
+		files, err := fs.ReadDir(dirname)
 		if err != nil {
 			return err
 		}
-		cmd.read.exclude.Set(conf)
+		cmd.read.exclude.Set(filepath.Base(conf))
 		targets := cmd.read.resolve(files)
-		var c config.Config
-		if err := yaml.Unmarshal(b, &c); err != nil {
-			return err
-		}
 		cc := &config.Config{Targets: targets}
 		c.Set(cmd.file, cc, config.ModeScan)
-		if b, err = marshalYAML(c); err != nil {
+		b, err := config.Encode(conf, c)
+		if err != nil {
 			return err
 		}
 		fi, err := fs.Stat(conf)
@@ -1,37 +1,25 @@
 package main
 
 import (
-	"fmt"
-
 	"github.com/gbrlsnchs/cli"
 	"github.com/gbrlsnchs/cli/cliutil"
-	"github.com/gbrlsnchs/pilgo/config"
-	"github.com/gbrlsnchs/pilgo/fs"
 	"github.com/gbrlsnchs/pilgo/parser"
-	"gopkg.in/yaml.v3"
 )
 
-type showCmd struct{
-	tags cliutil.CommaSepOptionSet
+type showCmd struct {
+	tags  cliutil.CommaSepOptionSet
+	print printMode
+	vars  bool
 }
 
 func (cmd *showCmd) register(getcfg func() appConfig) func(cli.Program) error {
 	return func(prg cli.Program) error {
 		appcfg := getcfg()
-		fs := fs.New(appcfg.fs)
-		b, err := fs.ReadFile(appcfg.conf)
+		c, err := appcfg.readConfig(prg)
 		if err != nil {
 			return err
 		}
-		c := new(config.Config)
-		if yaml.Unmarshal(b, c); err != nil {
-			return err
-		}
-		userConfigDir, err := appcfg.userConfigDir()
-		if err != nil {
-			return err
-		}
-		homeConfigDir, err := appcfg.userHomeDir()
+		baseDirs, err := appcfg.baseDirs()
 		if err != nil {
 			return err
 		}
@@ -41,17 +29,16 @@ func (cmd *showCmd) register(getcfg func() appConfig) func(cli.Program) error {
 		}
 		var p parser.Parser
 		tr, err := p.Parse(c,
-			parser.BaseDirs(map[parser.Mode]string{
-				parser.UserMode: userConfigDir,
-				parser.HomeMode: homeConfigDir,
-			}),
+			parser.BaseDirs(baseDirs),
 			parser.Cwd(cwd),
 			parser.Envsubst,
 			parser.Tags(cmd.tags))
 		if err != nil {
 			return err
 		}
-		fmt.Fprint(prg.Stdout(), tr)
-		return nil
+		if cmd.vars {
+			return fprintVars(prg.Stdout(), cmd.print.filter(tr))
+		}
+		return cmd.print.fprint(prg.Stdout(), tr, appcfg.getenv)
 	}
 }
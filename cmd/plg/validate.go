@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/gbrlsnchs/cli"
+	"github.com/gbrlsnchs/cli/cliutil"
+	"github.com/gbrlsnchs/pilgo/config"
+	"github.com/gbrlsnchs/pilgo/parser"
+)
+
+type validateCmd struct {
+	schema bool
+	tags   cliutil.CommaSepOptionSet
+}
+
+func (cmd *validateCmd) register(getcfg func() appConfig) func(cli.Program) error {
+	return func(prg cli.Program) error {
+		if cmd.schema {
+			b, err := config.Schema()
+			if err != nil {
+				return err
+			}
+			_, err = prg.Stdout().Write(b)
+			return err
+		}
+		appcfg := getcfg()
+		c, err := appcfg.readConfig(prg)
+		if err != nil {
+			return err
+		}
+		baseDirs, err := appcfg.baseDirs()
+		if err != nil {
+			return err
+		}
+		cwd, err := appcfg.getwd()
+		if err != nil {
+			return err
+		}
+		var p parser.Parser
+		tr, err := p.Parse(c,
+			parser.BaseDirs(baseDirs),
+			parser.Cwd(cwd),
+			parser.Envsubst,
+			parser.Tags(cmd.tags))
+		if err != nil {
+			return err
+		}
+		if err := tr.Validate(); err != nil {
+			var cerr *parser.CollisionError
+			if errors.As(err, &cerr) {
+				fprintCollisions(prg.Stderr(), prg.Name(), cerr)
+			}
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/gbrlsnchs/cli/clitest"
+	"github.com/gbrlsnchs/pilgo/config"
+	"github.com/gbrlsnchs/pilgo/fs/fstest"
+	"github.com/gbrlsnchs/pilgo/parser"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidate(t *testing.T) {
+	newDriver := func(data string) fstest.InMemoryDriver {
+		return fstest.InMemoryDriver{
+			CurrentDir: "home/dotfiles",
+			Files: map[string]fstest.File{
+				"home": {
+					Perm: os.ModePerm,
+					Children: map[string]fstest.File{
+						"dotfiles": {
+							Perm: os.ModePerm,
+							Children: map[string]fstest.File{
+								config.DefaultName: {Perm: 0o644, Data: []byte(data)},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	schema, err := config.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name   string
+		data   string
+		cmd    validateCmd
+		stdout string
+		stderr string
+		err    error
+	}{
+		{
+			name: "valid",
+			data: "targets:\n- foo\n- bar\noptions:\n  foo:\n    tags:\n    - test\n    link: bar\n",
+		},
+		{
+			name: "invalid",
+			data: "targets:\n- foo\nflatten: 1\noptions:\n  foo:\n    basedir: bar\n  bar:\n    link: baz\n",
+			stderr: "validate: config: pilgo.yml:3:10: invalid type: want a boolean, got a number\n" +
+				"validate: config: pilgo.yml:6:5: unknown field \"basedir\" (did you mean \"baseDir\"?)\n" +
+				"validate: config: pilgo.yml:7:3: options for unlisted target \"bar\"\n",
+			err: config.ErrUnknownField,
+		},
+		{
+			name: "targets with variables",
+			data: "vars:\n  NAME: foo\ntargets:\n- $NAME\n- bar\noptions:\n  foo:\n    link: baz\n",
+		},
+		{
+			name: "unlisted expanded target",
+			data: "vars:\n  NAME: foo\ntargets:\n- $NAME\noptions:\n  bar:\n    link: baz\n",
+			err:  config.ErrUnknownTarget,
+		},
+		{
+			name: "duplicate links with tags",
+			data: "targets:\n- foo\n- bar\noptions:\n  foo:\n    tags:\n    - test\n    link: bar\n",
+			cmd:  validateCmd{tags: map[string]struct{}{"test": {}}},
+			stderr: "validate: parser: " + fstest.AbsPath("home", "config", "bar") + ": duplicate link: bar, foo\n" +
+				"validate: hint: give targets distinct link names with \"plg config -link\", or don't flatten them\n",
+			err: parser.ErrDuplicateLink,
+		},
+		{
+			name:   "schema",
+			data:   "invalid",
+			cmd:    validateCmd{schema: true},
+			stdout: string(schema),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				drv    = newDriver(tc.data)
+				appcfg = appConfig{
+					conf:          config.DefaultName,
+					fs:            &drv,
+					getenv:        func(string) string { return "" },
+					getwd:         func() (string, error) { return fstest.AbsPath("home", "dotfiles"), nil },
+					userConfigDir: func() (string, error) { return fstest.AbsPath("home", "config"), nil },
+					userHomeDir:   func() (string, error) { return fstest.AbsPath("home"), nil },
+				}
+				exec = tc.cmd.register(appcfg.copy)
+				prg  = clitest.NewProgram("validate")
+				err  = exec(prg)
+			)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if want, got := tc.stdout, prg.Output(); got != want {
+				t.Errorf("\"validate\" command stdout mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			if want, got := tc.stderr, prg.ErrOutput(); got != want {
+				t.Errorf("\"validate\" command stderr mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			if want, got := newDriver(tc.data), drv; !cmp.Equal(got, want) {
+				t.Fatalf("\"validate\" command has unintended effects in the file system: (-want +got):\n%s",
+					cmp.Diff(want, got))
+			}
+		})
+	}
+}
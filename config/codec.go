@@ -0,0 +1,231 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownFormat means a configuration format couldn't be recognized.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Codec encodes and decodes configuration files in a specific format.
+type Codec interface {
+	// Marshal encodes c.
+	Marshal(c *Config) ([]byte, error)
+	// Unmarshal strictly decodes data read from filename into c. Unknown fields, values
+	// of the wrong type and options for targets not listed in targets are all reported
+	// at once by a *ValidationError. In that case, c is left untouched.
+	Unmarshal(filename string, b []byte, c *Config) error
+}
+
+var (
+	// YAML is the codec for YAML, the default format.
+	YAML Codec = yamlCodec{}
+	// TOML is the codec for TOML.
+	TOML Codec = tomlCodec{}
+	// JSON is the codec for JSON.
+	JSON Codec = jsonCodec{}
+)
+
+var formats = []struct {
+	name  string
+	codec Codec
+	exts  []string
+}{
+	{"yaml", YAML, []string{".yml", ".yaml"}},
+	{"toml", TOML, []string{".toml"}},
+	{"json", JSON, []string{".json"}},
+}
+
+// CodecFor returns the codec for filename according to its extension.
+// Files with unknown extensions are considered to be YAML.
+func CodecFor(filename string) Codec {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, f := range formats {
+		for _, e := range f.exts {
+			if e == ext {
+				return f.codec
+			}
+		}
+	}
+	return YAML
+}
+
+// NameFor returns the default name of configuration files in a given format,
+// which is either "yaml", "toml" or "json", regardless of its case.
+func NameFor(format string) (string, error) {
+	name := strings.ToLower(format)
+	for _, f := range formats {
+		if f.name == name {
+			return strings.TrimSuffix(DefaultName, filepath.Ext(DefaultName)) + f.exts[0], nil
+		}
+	}
+	return "", fmt.Errorf("config: %w %q", ErrUnknownFormat, format)
+}
+
+// DefaultNames returns the default names of configuration files in every format,
+// starting with DefaultName.
+func DefaultNames() []string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i], _ = NameFor(f.name)
+	}
+	return names
+}
+
+// Decode strictly decodes data read from filename into c using the codec for filename.
+func Decode(filename string, b []byte, c *Config) error {
+	return CodecFor(filename).Unmarshal(filename, b, c)
+}
+
+// Encode encodes c using the codec for filename.
+func Encode(filename string, c *Config) ([]byte, error) {
+	return CodecFor(filename).Marshal(c)
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) Marshal(c *Config) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (yamlCodec) Unmarshal(filename string, b []byte, c *Config) error {
+	return decodeYAML(filename, b, c)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(c *Config) ([]byte, error) {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Unmarshal checks JSON syntax and then decodes b as YAML, which is a superset
+// of JSON, since YAML data keeps track of lines and columns.
+func (jsonCodec) Unmarshal(filename string, b []byte, c *Config) error {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil // empty file
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("config: %s: %w", filename, err)
+	}
+	return decodeYAML(filename, b, c)
+}
+
+type tomlCodec struct{}
+
+func (tomlCodec) Marshal(c *Config) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes TOML data, whose keys have no known positions,
+// so problems are reported along with their enclosing table.
+func (tomlCodec) Unmarshal(filename string, b []byte, c *Config) error {
+	var cc Config
+	md, err := toml.Decode(string(b), &cc)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", filename, err)
+	}
+	var (
+		verr     = ValidationError{File: filename}
+		reported []toml.Key
+	)
+	// Keys are checked by hand, since TOML decoding ignores case
+	// and thus accepts fields like "basedir".
+	for _, key := range md.Keys() {
+		if hasPrefix(key, reported) {
+			continue // nested in a table already reported
+		}
+		// Keys alternate between fields and targets in options, e.g. "options.foo.link",
+		// or end with a name from a map of strings, e.g. "baseDirs.scripts".
+		for i := 0; i < len(key); i += 2 {
+			if typ, ok := fields[key[i]]; ok && (i == len(key)-1 || key[i] == "options" ||
+				i == len(key)-2 && typ.Kind() == reflect.Map && typ.Elem().Kind() == reflect.String) {
+				continue
+			}
+			reported = append(reported, key[:i+1])
+			verr.Errs = append(verr.Errs, &Error{
+				File: filename,
+				Key:  key[:i].String(),
+				Err:  unknownField(key[i]),
+			})
+			break
+		}
+	}
+	verr.Errs = append(verr.Errs, unlistedTargets(filename, &cc, nil)...)
+	if len(verr.Errs) > 0 {
+		return &verr
+	}
+	*c = cc
+	return nil
+}
+
+func hasPrefix(key toml.Key, prefixes []toml.Key) bool {
+	for _, p := range prefixes {
+		if len(p) <= len(key) && p.String() == key[:len(p)].String() {
+			return true
+		}
+	}
+	return false
+}
+
+// unlistedTargets returns errors for options set for targets not listed in c, as well
+// as for conflicting fields, recursively. Option keys are sorted, since their order
+// is not known.
+func unlistedTargets(filename string, c *Config, key toml.Key) []*Error {
+	targets := make(map[string]struct{}, len(c.Targets))
+	for _, tg := range c.Targets {
+		targets[tg] = struct{}{}
+	}
+	names := make([]string, 0, len(c.Options))
+	for name := range c.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []*Error
+	if c.Base != "" && c.UseHome != nil {
+		errs = append(errs, &Error{
+			File: filename,
+			Key:  key.String(),
+			Err:  fmt.Errorf("%w: base and useHome", ErrConflictingFields),
+		})
+	}
+	key = append(key[:len(key):len(key)], "options")
+	dynamic := hasVars(targets)
+	for _, name := range names {
+		if _, ok := targets[name]; !ok && !dynamic {
+			errs = append(errs, &Error{
+				File: filename,
+				Key:  key.String(),
+				Err:  fmt.Errorf("%w %q", ErrUnknownTarget, name),
+			})
+		}
+		if cc := c.Options[name]; cc != nil {
+			errs = append(errs, unlistedTargets(filename, cc, append(key[:len(key):len(key)], name))...)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,290 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/config"
+	"github.com/gbrlsnchs/pilgo/internal"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecode(t *testing.T) {
+	type problem struct {
+		Line, Column int
+		Msg          string
+	}
+	testCases := []struct {
+		name     string
+		data     string
+		want     config.Config
+		problems []problem
+		err      error
+	}{
+		{
+			name: "valid",
+			data: "baseDir: test\ntargets:\n- foo\n- bar\noptions:\n  foo:\n    useHome: true\n    targets:\n    - baz\n    options:\n      baz:\n        link: qux\n",
+			want: config.Config{
+				BaseDir: "test",
+				Targets: []string{"foo", "bar"},
+				Options: map[string]*config.Config{
+					"foo": {
+						UseHome: internal.NewBool(true),
+						Targets: []string{"baz"},
+						Options: map[string]*config.Config{
+							"baz": {Link: "qux"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "empty",
+			data: "",
+			want: config.Config{},
+		},
+		{
+			name: "unknown fields",
+			data: "basedir: test\ntargets:\n- foo\noptions:\n  foo:\n    flaten: true\n",
+			problems: []problem{
+				{1, 1, `unknown field "basedir" (did you mean "baseDir"?)`},
+				{6, 5, `unknown field "flaten"`},
+			},
+			err: config.ErrUnknownField,
+		},
+		{
+			name: "invalid types",
+			data: "targets: foo\nflatten: yes please\ntags:\n- [foo]\nbaseDirs:\n  bin: [foo]\n",
+			problems: []problem{
+				{1, 10, "invalid type: want a list, got a string"},
+				{2, 10, "invalid type: want a boolean, got a string"},
+				{4, 3, "invalid type: want a string, got a list"},
+				{6, 8, "invalid type: want a string, got a list"},
+			},
+			err: config.ErrInvalidType,
+		},
+		{
+			name: "unlisted targets",
+			data: "targets:\n- foo\noptions:\n  foo:\n    link: bar\n  bar:\n    link: foo\n",
+			problems: []problem{
+				{6, 3, `options for unlisted target "bar"`},
+			},
+			err: config.ErrUnknownTarget,
+		},
+		{
+			name: "targets with variables",
+			data: "vars:\n  NAME: baz\ntargets:\n- $FOO\n- ${NAME}\noptions:\n  bar:\n    link: qux\n  ${NAME}:\n    link: quux\n",
+			want: config.Config{
+				Vars:    map[string]string{"NAME": "baz"},
+				Targets: []string{"$FOO", "${NAME}"},
+				Options: map[string]*config.Config{
+					"bar":     {Link: "qux"},
+					"${NAME}": {Link: "quux"},
+				},
+			},
+		},
+		{
+			name: "conflicting fields",
+			data: "base: data\nuseHome: true\ntargets:\n- foo\noptions:\n  foo:\n    useHome: false\n    base: bin\n",
+			problems: []problem{
+				{2, 1, "conflicting fields: base and useHome"},
+				{7, 5, "conflicting fields: base and useHome"},
+			},
+			err: config.ErrConflictingFields,
+		},
+		{
+			name: "not a mapping",
+			data: "- foo\n",
+			problems: []problem{
+				{1, 1, "invalid type: want a mapping, got a list"},
+			},
+			err: config.ErrInvalidType,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c config.Config
+			err := config.Decode("pilgo.yml", []byte(tc.data), &c)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			var (
+				verr     *config.ValidationError
+				problems []problem
+			)
+			if errors.As(err, &verr) {
+				for _, e := range verr.Errs {
+					if want, got := "pilgo.yml", e.File; got != want {
+						t.Errorf("want %q, got %q", want, got)
+					}
+					problems = append(problems, problem{e.Line, e.Column, e.Err.Error()})
+				}
+			}
+			if want, got := tc.problems, problems; !cmp.Equal(got, want) {
+				t.Errorf("problems mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			if want, got := tc.want, c; !cmp.Equal(got, want, ignoreUnexported) {
+				t.Errorf("config mismatch (-want +got):\n%s", cmp.Diff(want, got, ignoreUnexported))
+			}
+		})
+	}
+}
+
+func TestCodec(t *testing.T) {
+	c := config.Config{
+		BaseDir:  "test",
+		BaseDirs: map[string]string{"scripts": "~/bin"},
+		UseHome:  internal.NewBool(false),
+		Targets:  []string{"foo", "bar"},
+		Vars:     map[string]string{"FOO": "foo"},
+		Options: map[string]*config.Config{
+			"foo": {
+				Base:    "scripts",
+				Flatten: true,
+				Targets: []string{"baz"},
+				Options: map[string]*config.Config{
+					"baz": {Link: "qux", Tags: []string{"test"}},
+				},
+			},
+		},
+	}
+	testCases := []struct {
+		filename string
+		codec    config.Codec
+	}{
+		{"pilgo.yml", config.YAML},
+		{"pilgo.yaml", config.YAML},
+		{"pilgo.toml", config.TOML},
+		{"pilgo.json", config.JSON},
+		{"PILGO.JSON", config.JSON},
+		{"pilgo", config.YAML},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.filename, func(t *testing.T) {
+			if want, got := tc.codec, config.CodecFor(tc.filename); got != want {
+				t.Fatalf("want %T, got %T", want, got)
+			}
+			b, err := config.Encode(tc.filename, &c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got config.Config
+			if err := config.Decode(tc.filename, b, &got); err != nil {
+				t.Fatalf("%v:\n%s", err, b)
+			}
+			if want := c; !cmp.Equal(got, want, ignoreUnexported) {
+				t.Errorf("round trip mismatch (-want +got):\n%s", cmp.Diff(want, got, ignoreUnexported))
+			}
+		})
+	}
+}
+
+func TestCodecStrict(t *testing.T) {
+	testCases := []struct {
+		filename string
+		data     string
+		problems []string
+		err      error
+	}{
+		{
+			filename: "pilgo.toml",
+			data:     "basedir = \"test\"\ntargets = [\"foo\"]\n\n[options.foo]\nflaten = true\n\n[options.bar]\nlink = \"baz\"\n\n[unknown]\nfoo = \"bar\"\n",
+			problems: []string{
+				`config: pilgo.toml: unknown field "basedir" (did you mean "baseDir"?)`,
+				`config: pilgo.toml: options.foo: unknown field "flaten"`,
+				`config: pilgo.toml: unknown field "unknown"`,
+				`config: pilgo.toml: options: options for unlisted target "bar"`,
+			},
+			err: config.ErrUnknownField,
+		},
+		{
+			// Options for targets with variables are checked by the parser.
+			filename: "pilgo.toml",
+			data:     "targets = [\"$FOO\", \"${NAME}\"]\n\n[vars]\nNAME = \"baz\"\n\n[options.bar]\nlink = \"qux\"\n\n[options.\"${NAME}\"]\nlink = \"quux\"\n",
+		},
+		{
+			filename: "pilgo.toml",
+			data:     "targets = [\"foo\"]\n\n[options.foo]\nbase = \"data\"\nuseHome = true\n",
+			problems: []string{
+				"config: pilgo.toml: options.foo: conflicting fields: base and useHome",
+			},
+			err: config.ErrConflictingFields,
+		},
+		{
+			filename: "pilgo.json",
+			data:     "{\n  \"targets\": [\"foo\"],\n  \"options\": {\n    \"foo\": {\"useHome\": \"yes\"}\n  }\n}\n",
+			problems: []string{
+				"config: pilgo.json:4:24: invalid type: want a boolean, got a string",
+			},
+			err: config.ErrInvalidType,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.filename, func(t *testing.T) {
+			var c config.Config
+			err := config.Decode(tc.filename, []byte(tc.data), &c)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			var (
+				verr     *config.ValidationError
+				problems []string
+			)
+			if errors.As(err, &verr) {
+				for _, e := range verr.Errs {
+					problems = append(problems, e.Error())
+				}
+			}
+			if want, got := tc.problems, problems; !cmp.Equal(got, want) {
+				t.Errorf("problems mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestCodecSyntaxError(t *testing.T) {
+	for _, filename := range []string{"pilgo.yml", "pilgo.toml", "pilgo.json"} {
+		t.Run(filename, func(t *testing.T) {
+			var c config.Config
+			err := config.Decode(filename, []byte("targets: [foo\n"), &c)
+			if err == nil {
+				t.Fatal("want an error, got nil")
+			}
+			var verr *config.ValidationError
+			if errors.As(err, &verr) {
+				t.Fatalf("want a syntax error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNameFor(t *testing.T) {
+	testCases := []struct {
+		format string
+		want   string
+		err    error
+	}{
+		{"yaml", "pilgo.yml", nil},
+		{"TOML", "pilgo.toml", nil},
+		{"json", "pilgo.json", nil},
+		{"xml", "", config.ErrUnknownFormat},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			name, err := config.NameFor(tc.format)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if want, got := tc.want, name; got != want {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultNames(t *testing.T) {
+	want := []string{"pilgo.yml", "pilgo.toml", "pilgo.json"}
+	if got := config.DefaultNames(); !cmp.Equal(got, want) {
+		t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
@@ -15,7 +15,7 @@ const (
 type SetMode int
 
 const (
-	// ModeConfig is the mode for setting every field except targets.
+	// ModeConfig is the mode for setting every field except targets, base directory aliases and variables.
 	ModeConfig SetMode = iota
 	// ModeScan is the mode for setting only targets.
 	ModeScan
@@ -23,13 +23,16 @@ const (
 
 // Config is a configuration format for Pilgo.
 type Config struct {
-	BaseDir string             `yaml:"baseDir,omitempty"`
-	Link    string             `yaml:"link,omitempty"`
-	Targets []string           `yaml:"targets,omitempty"`
-	Options map[string]*Config `yaml:"options,omitempty"`
-	Flatten bool               `yaml:"flatten,omitempty"`
-	UseHome *bool              `yaml:"useHome,omitempty"`
-	Tags    []string           `yaml:"tags,omitempty"`
+	BaseDir  string             `yaml:"baseDir,omitempty" toml:"baseDir,omitempty" json:"baseDir,omitempty"`
+	Base     string             `yaml:"base,omitempty" toml:"base,omitempty" json:"base,omitempty"`
+	BaseDirs map[string]string  `yaml:"baseDirs,omitempty" toml:"baseDirs,omitempty" json:"baseDirs,omitempty"`
+	Link     string             `yaml:"link,omitempty" toml:"link,omitempty" json:"link,omitempty"`
+	Targets  []string           `yaml:"targets,omitempty" toml:"targets,omitempty" json:"targets,omitempty"`
+	Options  map[string]*Config `yaml:"options,omitempty" toml:"options,omitempty" json:"options,omitempty"`
+	Flatten  bool               `yaml:"flatten,omitempty" toml:"flatten,omitempty" json:"flatten,omitempty"`
+	UseHome  *bool              `yaml:"useHome,omitempty" toml:"useHome,omitempty" json:"useHome,omitempty"`
+	Tags     []string           `yaml:"tags,omitempty" toml:"tags,omitempty" json:"tags,omitempty"`
+	Vars     map[string]string  `yaml:"vars,omitempty" toml:"vars,omitempty" json:"vars,omitempty"`
 }
 
 // Set sets o to path. The path may be nested, but will be a no-op if the
@@ -77,18 +80,23 @@ func (c *Config) Set(path string, new *Config, m SetMode) {
 
 func (c *Config) isEmpty() bool {
 	return c.BaseDir == "" &&
+		c.Base == "" &&
+		len(c.BaseDirs) == 0 &&
 		c.Link == "" &&
 		len(c.Targets) == 0 &&
 		len(c.Options) == 0 &&
 		c.UseHome == nil &&
 		!c.Flatten &&
-		len(c.Tags) == 0
+		len(c.Tags) == 0 &&
+		len(c.Vars) == 0
 }
 
 func (c *Config) resolveNew(new *Config, m SetMode) *Config {
 	switch m {
 	case ModeConfig:
 		new.Targets = c.Targets
+		new.BaseDirs = c.BaseDirs
+		new.Vars = c.Vars
 	case ModeScan:
 		tgs := new.Targets
 		*new = *c
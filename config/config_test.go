@@ -74,6 +74,21 @@ func testConfigSet(t *testing.T) {
 				Flatten: false,
 			},
 		},
+		{
+			c: config.Config{
+				BaseDirs: map[string]string{"scripts": "~/bin"},
+				Targets:  []string{"foo"},
+			},
+			name: "",
+			o: config.Config{
+				Base: "scripts",
+			},
+			want: config.Config{
+				Base:     "scripts",
+				BaseDirs: map[string]string{"scripts": "~/bin"},
+				Targets:  []string{"foo"},
+			},
+		},
 		{
 			c: config.Config{
 				Targets: []string{"foo"},
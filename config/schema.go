@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Schema returns a JSON Schema for the configuration format, generated from Config.
+// It can be used by editors to validate and complete configuration files.
+func Schema() ([]byte, error) {
+	t := reflect.TypeOf(Config{})
+	props := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		props[fieldName(f)] = schemaType(f.Type)
+	}
+	b, err := json.MarshalIndent(map[string]interface{}{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                "Pilgo configuration",
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func schemaType(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaType(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.String {
+			return map[string]interface{}{"type": "object", "additionalProperties": schemaType(t.Elem())}
+		}
+		// Options are nested configurations.
+		return map[string]interface{}{"type": "object", "additionalProperties": map[string]string{"$ref": "#"}}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
@@ -0,0 +1,26 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/config"
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestSchema checks that the published schema is up to date.
+// It can be regenerated by running "plg validate -schema".
+func TestSchema(t *testing.T) {
+	want, err := ioutil.ReadFile(filepath.Join("..", "pilgo.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := config.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(string(got), string(want)) {
+		t.Fatalf("pilgo.schema.json is outdated (-want +got):\n%s", cmp.Diff(string(want), string(got)))
+	}
+}
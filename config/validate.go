@@ -0,0 +1,267 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrUnknownField means a field doesn't exist in the configuration format.
+	ErrUnknownField = errors.New("unknown field")
+	// ErrInvalidType means a field's value has the wrong type.
+	ErrInvalidType = errors.New("invalid type")
+	// ErrUnknownTarget means options are set for a target that is not listed in targets.
+	ErrUnknownTarget = errors.New("options for unlisted target")
+	// ErrConflictingFields means fields that can't be set together are set.
+	ErrConflictingFields = errors.New("conflicting fields")
+)
+
+// Error is a problem found in a configuration file. Formats that don't keep track
+// of positions report the dotted path of the enclosing table instead, if any.
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Key    string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Line > 0:
+		return fmt.Sprintf("config: %s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+	case e.Key != "":
+		return fmt.Sprintf("config: %s: %s: %v", e.File, e.Key, e.Err)
+	default:
+		return fmt.Sprintf("config: %s: %v", e.File, e.Err)
+	}
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ValidationError lists every problem found in a configuration file.
+type ValidationError struct {
+	File string
+	Errs []*Error
+}
+
+func (e *ValidationError) Error() string {
+	if n := len(e.Errs); n != 1 {
+		return fmt.Sprintf("config: %s: there are %d problems", e.File, n)
+	}
+	return fmt.Sprintf("config: %s: there is 1 problem", e.File)
+}
+
+// Is reports whether any of the problems matches target.
+func (e *ValidationError) Is(target error) bool {
+	for _, err := range e.Errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// fields maps names of fields in the configuration format to their types.
+var fields = func() map[string]reflect.Type {
+	t := reflect.TypeOf(Config{})
+	m := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		m[fieldName(f)] = f.Type
+	}
+	return m
+}()
+
+func fieldName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("yaml"), ",")[0]
+}
+
+// decodeYAML strictly decodes YAML data read from filename into c.
+func decodeYAML(filename string, b []byte, c *Config) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("config: %s: %w", filename, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil // empty file
+	}
+	v := validator{file: filename}
+	v.validate(doc.Content[0])
+	if len(v.errs) > 0 {
+		return &ValidationError{filename, v.errs}
+	}
+	if err := doc.Decode(c); err != nil {
+		return fmt.Errorf("config: %s: %w", filename, err)
+	}
+	return nil
+}
+
+type validator struct {
+	file string
+	errs []*Error
+}
+
+func (v *validator) errorf(n *yaml.Node, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{
+		File:   v.file,
+		Line:   n.Line,
+		Column: n.Column,
+		Err:    fmt.Errorf(format, args...),
+	})
+}
+
+// validate checks a mapping node against the configuration format, recursively.
+func (v *validator) validate(n *yaml.Node) {
+	n = resolveAlias(n)
+	if n.Tag == "!!null" {
+		return
+	}
+	if n.Kind != yaml.MappingNode {
+		v.errorf(n, "%w: want a mapping, got %s", ErrInvalidType, kindName(n))
+		return
+	}
+	var (
+		targets       = make(map[string]struct{})
+		options       *yaml.Node
+		base, useHome *yaml.Node
+	)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, val := n.Content[i], resolveAlias(n.Content[i+1])
+		typ, ok := fields[key.Value]
+		if !ok {
+			v.errorf(key, "%w", unknownField(key.Value))
+			continue
+		}
+		if !v.checkType(val, typ) {
+			continue
+		}
+		switch key.Value {
+		case "targets":
+			for _, tg := range val.Content {
+				targets[resolveAlias(tg).Value] = struct{}{}
+			}
+		case "options":
+			options = val
+		case "base":
+			if val.Tag != "!!null" && val.Value != "" {
+				base = key
+			}
+		case "useHome":
+			if val.Tag != "!!null" {
+				useHome = key
+			}
+		}
+	}
+	if base != nil && useHome != nil {
+		v.errorf(useHome, "%w: base and useHome", ErrConflictingFields)
+	}
+	if options == nil {
+		return
+	}
+	dynamic := hasVars(targets)
+	for i := 0; i+1 < len(options.Content); i += 2 {
+		key := options.Content[i]
+		if _, ok := targets[key.Value]; !ok && !dynamic {
+			v.errorf(key, "%w %q", ErrUnknownTarget, key.Value)
+		}
+		v.validate(options.Content[i+1])
+	}
+}
+
+// hasVars reports whether any of targets references a variable. Options for such
+// targets can only be matched after expansion, so the parser checks them instead.
+func hasVars(targets map[string]struct{}) bool {
+	for tg := range targets {
+		if strings.Contains(tg, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownField returns an ErrUnknownField error, suggesting
+// a field that only differs from key by case, if any.
+func unknownField(key string) error {
+	for name := range fields {
+		if strings.EqualFold(name, key) {
+			return fmt.Errorf("%w %q (did you mean %q?)", ErrUnknownField, key, name)
+		}
+	}
+	return fmt.Errorf("%w %q", ErrUnknownField, key)
+}
+
+// checkType reports whether n can be decoded into a value of type typ.
+// Options are not checked, since they're validated recursively.
+func (v *validator) checkType(n *yaml.Node, typ reflect.Type) bool {
+	if n.Tag == "!!null" {
+		return true
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	var want string
+	switch typ.Kind() {
+	case reflect.Bool:
+		if n.Kind == yaml.ScalarNode && n.Tag == "!!bool" {
+			return true
+		}
+		want = "a boolean"
+	case reflect.String:
+		if n.Kind == yaml.ScalarNode {
+			return true
+		}
+		want = "a string"
+	case reflect.Slice:
+		if n.Kind == yaml.SequenceNode {
+			ok := true
+			for _, c := range n.Content {
+				ok = v.checkType(resolveAlias(c), typ.Elem()) && ok
+			}
+			return ok
+		}
+		want = "a list"
+	case reflect.Map:
+		if n.Kind == yaml.MappingNode {
+			if typ.Elem().Kind() != reflect.String {
+				return true
+			}
+			ok := true
+			for i := 1; i < len(n.Content); i += 2 {
+				ok = v.checkType(resolveAlias(n.Content[i]), typ.Elem()) && ok
+			}
+			return ok
+		}
+		want = "a mapping"
+	}
+	v.errorf(n, "%w: want %s, got %s", ErrInvalidType, want, kindName(n))
+	return false
+}
+
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+func kindName(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.MappingNode:
+		return "a mapping"
+	}
+	switch n.Tag {
+	case "!!bool":
+		return "a boolean"
+	case "!!int", "!!float":
+		return "a number"
+	default:
+		return "a string"
+	}
+}
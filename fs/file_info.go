@@ -1,12 +1,26 @@
 package fs
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 // FileInfo describes information about a file.
 type FileInfo interface {
 	Name() string
 	Exists() bool
 	IsDir() bool
+	IsSymlink() bool
 	Linkname() string
 	Perm() os.FileMode
+	// Mode returns both the type and permission bits of a file.
+	Mode() os.FileMode
+	ModTime() time.Time
+	Size() int64
+}
+
+// IsSpecial reports whether fi is neither a regular file, a directory nor a symlink,
+// for example, a named pipe, a socket or a device.
+func IsSpecial(fi FileInfo) bool {
+	return fi.Mode()&os.ModeType&^(os.ModeDir|os.ModeSymlink) != 0
 }
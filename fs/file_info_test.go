@@ -0,0 +1,31 @@
+package fs_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+	"github.com/gbrlsnchs/pilgo/fs/fstest"
+)
+
+func TestIsSpecial(t *testing.T) {
+	testCases := []struct {
+		mode os.FileMode
+		want bool
+	}{
+		{0o644, false},
+		{os.ModeDir | 0o755, false},
+		{os.ModeSymlink | 0o777, false},
+		{os.ModeNamedPipe | 0o644, true},
+		{os.ModeSocket | 0o755, true},
+		{os.ModeDevice | os.ModeCharDevice | 0o666, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.mode.String(), func(t *testing.T) {
+			fi := fstest.StubFile{ModeReturn: tc.mode}
+			if want, got := tc.want, fs.IsSpecial(fi); got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+		})
+	}
+}
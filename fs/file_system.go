@@ -2,12 +2,20 @@ package fs
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
-// ErrNoDriver is the error for a nonfunctional file system.
-var ErrNoDriver = errors.New("fs: nil driver")
+// maxLinks is how many symlinks are followed before giving up.
+const maxLinks = 40
+
+var (
+	// ErrNoDriver is the error for a nonfunctional file system.
+	ErrNoDriver = errors.New("fs: nil driver")
+	// ErrTooManyLinks means too many symlinks were followed when resolving a file.
+	ErrTooManyLinks = errors.New("too many levels of symbolic links")
+)
 
 // FileSystem is a concrete file system that implements a VFS contract.
 type FileSystem struct {
@@ -15,20 +23,52 @@ type FileSystem struct {
 }
 
 // Driver is the internal file system implementation.
+//
+// Errors for nonexistent files must match os.ErrNotExist when using errors.Is.
+// The same goes for os.ErrExist when a file unexpectedly exists.
 type Driver interface {
+	Chmod(name string, perm os.FileMode) error
+	Lchown(name string, uid, gid int) error
 	MkdirAll(dirname string) error
 	ReadDir(dirname string) ([]FileInfo, error)
 	ReadFile(filename string) ([]byte, error)
+	Readlink(name string) (string, error)
+	Remove(name string) error
+	RemoveAll(name string) error
+	Rename(oldname, newname string) error
+	// Stat must not follow symlinks. Also, instead of returning an error
+	// for a nonexistent file, it returns information reporting so.
 	Stat(filename string) (FileInfo, error)
 	Symlink(oldname, newname string) error
 	WriteFile(filename string, data []byte, perm os.FileMode) error
 }
 
+// StatFollower is an optional interface for drivers that are able to
+// read information about the file a symlink points to.
+type StatFollower interface {
+	StatFollow(filename string) (FileInfo, error)
+}
+
 // New creates a new FileSystem with drv as its engine.
 func New(drv Driver) FileSystem {
 	return FileSystem{drv}
 }
 
+// Chmod changes the permission of a file.
+func (fs FileSystem) Chmod(name string, perm os.FileMode) error {
+	fs.testDriver()
+	name = filepath.FromSlash(name)
+	return fs.drv.Chmod(name, perm)
+}
+
+// Lchown changes the owner of a file without following symlinks.
+// A negative uid or gid is left unchanged.
+func (fs FileSystem) Lchown(name string, uid, gid int) error {
+	fs.testDriver()
+	name = filepath.FromSlash(name)
+	return fs.drv.Lchown(name, uid, gid)
+}
+
 // MkdirAll creates directories and their parents, if needed.
 func (fs FileSystem) MkdirAll(dirname string) error {
 	fs.testDriver()
@@ -50,13 +90,66 @@ func (fs FileSystem) ReadFile(filename string) ([]byte, error) {
 	return fs.drv.ReadFile(filename)
 }
 
-// Stat returns information about a file.
+// Readlink returns the name of the file a symlink points to.
+func (fs FileSystem) Readlink(name string) (string, error) {
+	fs.testDriver()
+	name = filepath.FromSlash(name)
+	return fs.drv.Readlink(name)
+}
+
+// Remove removes a file or an empty directory.
+func (fs FileSystem) Remove(name string) error {
+	fs.testDriver()
+	name = filepath.FromSlash(name)
+	return fs.drv.Remove(name)
+}
+
+// RemoveAll removes a file and all of its children, if any.
+// It is a NOP when the file doesn't exist.
+func (fs FileSystem) RemoveAll(name string) error {
+	fs.testDriver()
+	name = filepath.FromSlash(name)
+	return fs.drv.RemoveAll(name)
+}
+
+// Rename moves oldname to newname.
+func (fs FileSystem) Rename(oldname, newname string) error {
+	fs.testDriver()
+	oldname = filepath.FromSlash(oldname)
+	newname = filepath.FromSlash(newname)
+	return fs.drv.Rename(oldname, newname)
+}
+
+// Stat returns information about a file. If the file is a symlink,
+// information about the symlink itself is returned.
 func (fs FileSystem) Stat(filename string) (FileInfo, error) {
 	fs.testDriver()
 	filename = filepath.FromSlash(filename)
 	return fs.drv.Stat(filename)
 }
 
+// StatFollow returns information about a file, following symlinks. If the driver
+// doesn't implement StatFollower, symlinks are followed by using their linknames.
+func (fs FileSystem) StatFollow(filename string) (FileInfo, error) {
+	fs.testDriver()
+	filename = filepath.FromSlash(filename)
+	if sf, ok := fs.drv.(StatFollower); ok {
+		return sf.StatFollow(filename)
+	}
+	for i := 0; i < maxLinks; i++ {
+		fi, err := fs.drv.Stat(filename)
+		if err != nil || fi.Linkname() == "" {
+			return fi, err
+		}
+		linkname := fi.Linkname()
+		if !filepath.IsAbs(linkname) {
+			linkname = filepath.Join(filepath.Dir(filename), linkname)
+		}
+		filename = linkname
+	}
+	return nil, fmt.Errorf("fs: %s: %w", filename, ErrTooManyLinks)
+}
+
 // Symlink creates a symlink of oldname as newname.
 func (fs FileSystem) Symlink(oldname, newname string) error {
 	fs.testDriver()
@@ -12,13 +12,72 @@ import (
 )
 
 func TestFileSystem(t *testing.T) {
+	t.Run("Chmod", testFileSystemChmod)
+	t.Run("Lchown", testFileSystemLchown)
 	t.Run("MkdirAll", testFileSystemMkdirAll)
 	t.Run("ReadDir", testFileSystemReadDir)
 	t.Run("ReadFile", testFileSystemReadFile)
+	t.Run("Readlink", testFileSystemReadlink)
+	t.Run("Remove", testFileSystemRemove)
+	t.Run("RemoveAll", testFileSystemRemoveAll)
+	t.Run("Rename", testFileSystemRename)
 	t.Run("Stat", testFileSystemStat)
+	t.Run("StatFollow", testFileSystemStatFollow)
 	t.Run("WriteFile", testFileSystemWriteFile)
 }
 
+func testFileSystemChmod(t *testing.T) {
+	testCases := []struct {
+		drv fs.Driver
+		err error
+	}{
+		{nil, fs.ErrNoDriver},
+		{new(fstest.SpyDriver), nil},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			defer checkPanic(t, tc.err)
+			fs := fs.New(tc.drv)
+			_ = fs.Chmod("test/foo", 0o600)
+			drv := tc.drv.(*fstest.SpyDriver)
+			hasBeenCalled, args := drv.HasBeenCalled(drv.Chmod)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{fstest.Args{filepath.Join("test", "foo"), os.FileMode(0o600)}}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("FileSystem.Chmod mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func testFileSystemLchown(t *testing.T) {
+	testCases := []struct {
+		drv fs.Driver
+		err error
+	}{
+		{nil, fs.ErrNoDriver},
+		{new(fstest.SpyDriver), nil},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			defer checkPanic(t, tc.err)
+			fs := fs.New(tc.drv)
+			_ = fs.Lchown("test/foo", 1000, -1)
+			drv := tc.drv.(*fstest.SpyDriver)
+			hasBeenCalled, args := drv.HasBeenCalled(drv.Lchown)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{fstest.Args{filepath.Join("test", "foo"), 1000, -1}}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("FileSystem.Lchown mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
 func testFileSystemMkdirAll(t *testing.T) {
 	testCases := []struct {
 		drv fs.Driver
@@ -97,6 +156,110 @@ func testFileSystemReadFile(t *testing.T) {
 	}
 }
 
+func testFileSystemReadlink(t *testing.T) {
+	testCases := []struct {
+		drv fs.Driver
+		err error
+	}{
+		{nil, fs.ErrNoDriver},
+		{new(fstest.SpyDriver), nil},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			defer checkPanic(t, tc.err)
+			fs := fs.New(tc.drv)
+			_, _ = fs.Readlink("test/foo")
+			drv := tc.drv.(*fstest.SpyDriver)
+			hasBeenCalled, args := drv.HasBeenCalled(drv.Readlink)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{fstest.Args{filepath.Join("test", "foo")}}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("FileSystem.Readlink mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func testFileSystemRemove(t *testing.T) {
+	testCases := []struct {
+		drv fs.Driver
+		err error
+	}{
+		{nil, fs.ErrNoDriver},
+		{new(fstest.SpyDriver), nil},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			defer checkPanic(t, tc.err)
+			fs := fs.New(tc.drv)
+			_ = fs.Remove("test/foo")
+			drv := tc.drv.(*fstest.SpyDriver)
+			hasBeenCalled, args := drv.HasBeenCalled(drv.Remove)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{fstest.Args{filepath.Join("test", "foo")}}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("FileSystem.Remove mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func testFileSystemRemoveAll(t *testing.T) {
+	testCases := []struct {
+		drv fs.Driver
+		err error
+	}{
+		{nil, fs.ErrNoDriver},
+		{new(fstest.SpyDriver), nil},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			defer checkPanic(t, tc.err)
+			fs := fs.New(tc.drv)
+			_ = fs.RemoveAll("test/foo")
+			drv := tc.drv.(*fstest.SpyDriver)
+			hasBeenCalled, args := drv.HasBeenCalled(drv.RemoveAll)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{fstest.Args{filepath.Join("test", "foo")}}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("FileSystem.RemoveAll mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func testFileSystemRename(t *testing.T) {
+	testCases := []struct {
+		drv fs.Driver
+		err error
+	}{
+		{nil, fs.ErrNoDriver},
+		{new(fstest.SpyDriver), nil},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			defer checkPanic(t, tc.err)
+			fs := fs.New(tc.drv)
+			_ = fs.Rename("test/foo", "test/bar")
+			drv := tc.drv.(*fstest.SpyDriver)
+			hasBeenCalled, args := drv.HasBeenCalled(drv.Rename)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{fstest.Args{filepath.Join("test", "foo"), filepath.Join("test", "bar")}}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("FileSystem.Rename mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
 func testFileSystemStat(t *testing.T) {
 	testCases := []struct {
 		drv fs.Driver
@@ -123,6 +286,68 @@ func testFileSystemStat(t *testing.T) {
 	}
 }
 
+func testFileSystemStatFollow(t *testing.T) {
+	t.Run("StatFollower", func(t *testing.T) {
+		drv := new(fstest.SpyDriver)
+		fs := fs.New(drv)
+		_, _ = fs.StatFollow("test/foo")
+		hasBeenCalled, args := drv.HasBeenCalled(drv.StatFollow)
+		if want, got := true, hasBeenCalled; got != want {
+			t.Fatalf("want %t, got %t", want, got)
+		}
+		callstack := fstest.CallStack{fstest.Args{filepath.Join("test", "foo")}}
+		if want, got := callstack, args; !cmp.Equal(got, want) {
+			t.Fatalf("FileSystem.StatFollow mismatch (-want +got):\n%s", cmp.Diff(want, got))
+		}
+	})
+	t.Run("Fallback", func(t *testing.T) {
+		testCases := []struct {
+			name  string
+			files map[string]fstest.File
+			isDir bool
+			err   error
+		}{
+			{
+				name: "symlink",
+				files: map[string]fstest.File{
+					"foo": {Linkname: filepath.Join("bar", "qux")},
+					"bar": {Children: map[string]fstest.File{
+						"qux": {Linkname: filepath.Join("..", "baz")},
+					}},
+					"baz": {Children: map[string]fstest.File{}},
+				},
+				isDir: true,
+				err:   nil,
+			},
+			{
+				name: "loop",
+				files: map[string]fstest.File{
+					"foo": {Linkname: "bar"},
+					"bar": {Linkname: "foo"},
+				},
+				isDir: false,
+				err:   fs.ErrTooManyLinks,
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Embedding only the interface hides the driver's StatFollow method.
+				drv := struct{ fs.Driver }{&fstest.InMemoryDriver{Files: tc.files}}
+				fi, err := fs.New(drv).StatFollow("foo")
+				if want, got := tc.err, err; !errors.Is(got, want) {
+					t.Fatalf("want %v, got %v", want, got)
+				}
+				if err != nil {
+					return
+				}
+				if want, got := tc.isDir, fi.IsDir(); got != want {
+					t.Fatalf("want %t, got %t", want, got)
+				}
+			})
+		}
+	})
+}
+
 func testFileSystemSymlink(t *testing.T) {
 	testCases := []struct {
 		drv fs.Driver
@@ -0,0 +1,418 @@
+package fstest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+)
+
+// TestDriver runs a conformance test suite against drivers created by newDriver,
+// checking whether they behave like a real file system.
+//
+// A new driver is created for every test, which is passed to newDriver so that
+// it can fail or clean up after itself. It must be empty and resolve relative
+// paths to a directory exclusive to the test, since every path used is relative.
+//
+// Permissions of created directories are not checked, since they depend on umask.
+func TestDriver(t *testing.T, newDriver func(*testing.T) fs.Driver) {
+	t.Run("Chmod", func(t *testing.T) { testDriverChmod(t, newDriver(t)) })
+	t.Run("Lchown", func(t *testing.T) { testDriverLchown(t, newDriver(t)) })
+	t.Run("MkdirAll", func(t *testing.T) { testDriverMkdirAll(t, newDriver(t)) })
+	t.Run("ReadDir", func(t *testing.T) { testDriverReadDir(t, newDriver(t)) })
+	t.Run("ReadFile", func(t *testing.T) { testDriverReadFile(t, newDriver(t)) })
+	t.Run("Readlink", func(t *testing.T) { testDriverReadlink(t, newDriver(t)) })
+	t.Run("Remove", func(t *testing.T) { testDriverRemove(t, newDriver(t)) })
+	t.Run("RemoveAll", func(t *testing.T) { testDriverRemoveAll(t, newDriver(t)) })
+	t.Run("Rename", func(t *testing.T) { testDriverRename(t, newDriver(t)) })
+	t.Run("Stat", func(t *testing.T) { testDriverStat(t, newDriver(t)) })
+	t.Run("Symlink", func(t *testing.T) { testDriverSymlink(t, newDriver(t)) })
+	t.Run("SymlinkedDir", func(t *testing.T) { testDriverSymlinkedDir(t, newDriver(t)) })
+	t.Run("WriteFile", func(t *testing.T) { testDriverWriteFile(t, newDriver(t)) })
+}
+
+func testDriverChmod(t *testing.T, drv fs.Driver) {
+	must(t, drv.WriteFile("foo", []byte("foo"), 0o644))
+	if err := drv.Chmod("foo", 0o600); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	// Windows only supports toggling the read-only flag.
+	if runtime.GOOS != "windows" {
+		fi := stat(t, drv, "foo")
+		if want, got := os.FileMode(0o600), fi.Perm(); got != want {
+			t.Errorf("want %#o, got %#o", want, got)
+		}
+	}
+	if want, got := os.ErrNotExist, drv.Chmod("bar", 0o600); !errors.Is(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func testDriverLchown(t *testing.T, drv fs.Driver) {
+	must(t, drv.WriteFile("foo", []byte("foo"), 0o644))
+	if err := drv.Lchown("foo", -1, -1); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	if want, got := os.ErrNotExist, drv.Lchown("bar", -1, -1); !errors.Is(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func testDriverMkdirAll(t *testing.T, drv fs.Driver) {
+	dirname := filepath.Join("foo", "bar", "baz")
+	if err := drv.MkdirAll(dirname); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	for _, name := range []string{"foo", filepath.Join("foo", "bar"), dirname} {
+		if fi := stat(t, drv, name); !fi.Exists() || !fi.IsDir() {
+			t.Errorf("want %q to be a directory", name)
+		}
+	}
+	if err := drv.MkdirAll(dirname); err != nil {
+		t.Errorf("want <nil> for an existing directory, got %v", err)
+	}
+	must(t, drv.WriteFile("qux", []byte("qux"), 0o644))
+	if err := drv.MkdirAll(filepath.Join("qux", "quux")); err == nil {
+		t.Error("want an error for a parent that is a regular file, got <nil>")
+	}
+}
+
+func testDriverReadDir(t *testing.T, drv fs.Driver) {
+	must(t, drv.MkdirAll(filepath.Join("foo", "bar")))
+	must(t, drv.WriteFile(filepath.Join("foo", "qux"), []byte("qux"), 0o644))
+	must(t, drv.Symlink("qux", filepath.Join("foo", "baz")))
+	must(t, drv.Symlink("foo", "link"))
+	type entry struct {
+		name     string
+		isDir    bool
+		linkname string
+	}
+	want := []entry{
+		{"bar", true, ""},
+		{"baz", false, "qux"},
+		{"qux", false, ""},
+	}
+	// Symlinks to directories are listed as well.
+	for _, dirname := range []string{"foo", "link"} {
+		files, err := drv.ReadDir(dirname)
+		if err != nil {
+			t.Fatalf("want <nil>, got %v", err)
+		}
+		if want, got := len(want), len(files); got != want {
+			t.Fatalf("want %d files, got %d", want, got)
+		}
+		for i, fi := range files {
+			got := entry{fi.Name(), fi.IsDir(), fi.Linkname()}
+			if want := want[i]; got != want {
+				t.Errorf("want %+v, got %+v", want, got)
+			}
+		}
+	}
+	// Relative linknames are resolved from the directory containing the symlink.
+	for _, name := range []string{filepath.Join("foo", "baz"), filepath.Join("link", "baz")} {
+		readFile(t, drv, name, "qux")
+		fi, err := fs.New(drv).StatFollow(name)
+		if err != nil {
+			t.Fatalf("want <nil>, got %v", err)
+		}
+		if !fi.Exists() || fi.IsDir() {
+			t.Errorf("want %q to be a regular file", name)
+		}
+	}
+	if _, err := drv.ReadDir("bar"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want %v, got %v", os.ErrNotExist, err)
+	}
+	if _, err := drv.ReadDir(filepath.Join("foo", "qux")); err == nil {
+		t.Error("want an error for a regular file, got <nil>")
+	}
+}
+
+func testDriverReadFile(t *testing.T, drv fs.Driver) {
+	must(t, drv.MkdirAll("foo"))
+	must(t, drv.WriteFile("bar", []byte("bar"), 0o644))
+	must(t, drv.Symlink("bar", "baz"))
+	// Symlinks are followed.
+	for _, name := range []string{"bar", "baz"} {
+		b, err := drv.ReadFile(name)
+		if err != nil {
+			t.Fatalf("want <nil>, got %v", err)
+		}
+		if want, got := "bar", string(b); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+	if _, err := drv.ReadFile("qux"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want %v, got %v", os.ErrNotExist, err)
+	}
+	if _, err := drv.ReadFile("foo"); err == nil {
+		t.Error("want an error for a directory, got <nil>")
+	}
+	if _, err := drv.ReadFile(filepath.Join("bar", "qux")); err == nil {
+		t.Error("want an error for a parent that is a regular file, got <nil>")
+	}
+}
+
+func testDriverReadlink(t *testing.T, drv fs.Driver) {
+	must(t, drv.WriteFile("foo", []byte("foo"), 0o644))
+	must(t, drv.Symlink("foo", "bar"))
+	linkname, err := drv.Readlink("bar")
+	if err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	if want, got := "foo", linkname; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if _, err := drv.Readlink("foo"); err == nil {
+		t.Error("want an error for a regular file, got <nil>")
+	}
+	if _, err := drv.Readlink("baz"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want %v, got %v", os.ErrNotExist, err)
+	}
+}
+
+func testDriverRemove(t *testing.T, drv fs.Driver) {
+	must(t, drv.MkdirAll("foo"))
+	must(t, drv.WriteFile(filepath.Join("foo", "bar"), []byte("bar"), 0o644))
+	must(t, drv.Symlink("foo", "baz"))
+	if err := drv.Remove("foo"); err == nil {
+		t.Error("want an error for a directory with children, got <nil>")
+	}
+	// Removing a symlink doesn't remove the file it points to.
+	if err := drv.Remove("baz"); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	if fi := stat(t, drv, "foo"); !fi.Exists() {
+		t.Error(`want "foo" not to be removed`)
+	}
+	for _, name := range []string{filepath.Join("foo", "bar"), "foo"} {
+		if err := drv.Remove(name); err != nil {
+			t.Fatalf("want <nil>, got %v", err)
+		}
+		if fi := stat(t, drv, name); fi.Exists() {
+			t.Errorf("want %q to be removed", name)
+		}
+	}
+	if want, got := os.ErrNotExist, drv.Remove("foo"); !errors.Is(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func testDriverRemoveAll(t *testing.T, drv fs.Driver) {
+	must(t, drv.MkdirAll(filepath.Join("foo", "bar")))
+	must(t, drv.WriteFile(filepath.Join("foo", "bar", "baz"), []byte("baz"), 0o644))
+	if err := drv.RemoveAll("foo"); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	if fi := stat(t, drv, "foo"); fi.Exists() {
+		t.Error(`want "foo" to be removed`)
+	}
+	if err := drv.RemoveAll("foo"); err != nil {
+		t.Errorf("want <nil> for a nonexistent file, got %v", err)
+	}
+	if err := drv.RemoveAll(filepath.Join("foo", "bar")); err != nil {
+		t.Errorf("want <nil> for a nonexistent parent, got %v", err)
+	}
+}
+
+func testDriverRename(t *testing.T, drv fs.Driver) {
+	must(t, drv.MkdirAll("foo"))
+	must(t, drv.WriteFile("bar", []byte("bar"), 0o644))
+	must(t, drv.WriteFile("qux", []byte("qux"), 0o644))
+	newname := filepath.Join("foo", "baz")
+	if err := drv.Rename("bar", newname); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	if fi := stat(t, drv, "bar"); fi.Exists() {
+		t.Error(`want "bar" to be moved`)
+	}
+	readFile(t, drv, newname, "bar")
+	// Regular files are replaced.
+	if err := drv.Rename("qux", newname); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	readFile(t, drv, newname, "qux")
+	if want, got := os.ErrNotExist, drv.Rename("bar", "quux"); !errors.Is(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if want, got := os.ErrNotExist, drv.Rename(newname, filepath.Join("quux", "baz")); !errors.Is(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	must(t, drv.MkdirAll(filepath.Join("quux", "corge")))
+	if err := drv.Rename(newname, "quux"); err == nil {
+		t.Error("want an error for a directory with children, got <nil>")
+	}
+}
+
+func testDriverStat(t *testing.T, drv fs.Driver) {
+	must(t, drv.MkdirAll("foo"))
+	must(t, drv.WriteFile("bar", []byte("bar"), 0o640))
+	must(t, drv.Symlink("foo", "baz"))
+	testCases := []struct {
+		name     string
+		exists   bool
+		isDir    bool
+		linkname string
+		modeType os.FileMode
+	}{
+		{"foo", true, true, "", os.ModeDir},
+		{"bar", true, false, "", 0},
+		{"baz", true, false, "foo", os.ModeSymlink}, // symlinks are not followed
+		{"qux", false, false, "", 0},
+	}
+	for _, tc := range testCases {
+		fi := stat(t, drv, tc.name)
+		if want, got := tc.exists, fi.Exists(); got != want {
+			t.Errorf("%s: want %t, got %t", tc.name, want, got)
+		}
+		if !tc.exists {
+			continue
+		}
+		if want, got := tc.name, fi.Name(); got != want {
+			t.Errorf("%s: want %q, got %q", tc.name, want, got)
+		}
+		if want, got := tc.isDir, fi.IsDir(); got != want {
+			t.Errorf("%s: want %t, got %t", tc.name, want, got)
+		}
+		if want, got := tc.linkname, fi.Linkname(); got != want {
+			t.Errorf("%s: want %q, got %q", tc.name, want, got)
+		}
+		if want, got := tc.linkname != "", fi.IsSymlink(); got != want {
+			t.Errorf("%s: want %t, got %t", tc.name, want, got)
+		}
+		if want, got := tc.modeType, fi.Mode()&os.ModeType; got != want {
+			t.Errorf("%s: want %v, got %v", tc.name, want, got)
+		}
+		if want, got := fi.Perm(), fi.Mode().Perm(); got != want {
+			t.Errorf("%s: want %#o, got %#o", tc.name, want, got)
+		}
+		if fs.IsSpecial(fi) {
+			t.Errorf("%s: want regular file, directory or symlink", tc.name)
+		}
+	}
+	if want, got := int64(3), stat(t, drv, "bar").Size(); got != want {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if runtime.GOOS != "windows" {
+		if want, got := os.FileMode(0o640), stat(t, drv, "bar").Perm(); got != want {
+			t.Errorf("want %#o, got %#o", want, got)
+		}
+	}
+	if _, err := drv.Stat(filepath.Join("bar", "qux")); err == nil {
+		t.Error("want an error for a parent that is a regular file, got <nil>")
+	}
+	fi, err := fs.New(drv).StatFollow("baz")
+	if err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	if !fi.IsDir() {
+		t.Error("want symlink to be followed")
+	}
+}
+
+func testDriverSymlink(t *testing.T, drv fs.Driver) {
+	must(t, drv.WriteFile("foo", []byte("foo"), 0o644))
+	if err := drv.Symlink("foo", "bar"); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	readFile(t, drv, "bar", "foo")
+	// Dangling symlinks are allowed.
+	if err := drv.Symlink("qux", "baz"); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	if fi := stat(t, drv, "baz"); !fi.Exists() {
+		t.Error(`want "baz" to exist`)
+	}
+	if want, got := os.ErrExist, drv.Symlink("foo", "bar"); !errors.Is(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	// Parent directories are not created.
+	if want, got := os.ErrNotExist, drv.Symlink("foo", filepath.Join("quux", "bar")); !errors.Is(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func testDriverSymlinkedDir(t *testing.T, drv fs.Driver) {
+	must(t, drv.MkdirAll(filepath.Join("foo", "bar")))
+	must(t, drv.WriteFile(filepath.Join("foo", "bar", "baz"), []byte("baz"), 0o644))
+	must(t, drv.Symlink("foo", "qux"))
+	must(t, drv.Symlink(filepath.Join("qux", "bar"), "quux"))
+	// Symlinks are resolved when they're parent directories, even if nested.
+	for _, name := range []string{
+		filepath.Join("qux", "bar", "baz"),
+		filepath.Join("quux", "baz"),
+	} {
+		if fi := stat(t, drv, name); !fi.Exists() || fi.IsDir() {
+			t.Errorf("want %q to be a regular file", name)
+		}
+		readFile(t, drv, name, "baz")
+	}
+	// A relative linkname in a subdirectory is resolved from that subdirectory.
+	must(t, drv.Symlink("bar", filepath.Join("foo", "thud")))
+	name := filepath.Join("foo", "thud", "baz")
+	if fi := stat(t, drv, name); !fi.Exists() || fi.IsDir() {
+		t.Errorf("want %q to be a regular file", name)
+	}
+	readFile(t, drv, name, "baz")
+	must(t, drv.MkdirAll(filepath.Join("quux", "corge")))
+	must(t, drv.WriteFile(filepath.Join("quux", "corge", "grault"), []byte("grault"), 0o644))
+	readFile(t, drv, filepath.Join("foo", "bar", "corge", "grault"), "grault")
+	must(t, drv.WriteFile(filepath.Join("quux", "garply"), []byte("garply"), 0o644))
+	readFile(t, drv, filepath.Join("foo", "bar", "garply"), "garply")
+	must(t, drv.Rename(filepath.Join("quux", "garply"), filepath.Join("qux", "garply")))
+	readFile(t, drv, filepath.Join("foo", "garply"), "garply")
+	must(t, drv.Remove(filepath.Join("qux", "garply")))
+	must(t, drv.Symlink("plugh", "waldo"))
+	must(t, drv.Symlink("waldo", "plugh"))
+	if _, err := drv.Stat(filepath.Join("waldo", "fred")); err == nil {
+		t.Error("want an error for a symlink loop, got <nil>")
+	}
+}
+
+func testDriverWriteFile(t *testing.T, drv fs.Driver) {
+	must(t, drv.MkdirAll("foo"))
+	if err := drv.WriteFile("bar", []byte("bar"), 0o644); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	readFile(t, drv, "bar", "bar")
+	if err := drv.WriteFile("bar", []byte("baz"), 0o644); err != nil {
+		t.Fatalf("want <nil>, got %v", err)
+	}
+	readFile(t, drv, "bar", "baz")
+	if err := drv.WriteFile("foo", []byte("foo"), 0o644); err == nil {
+		t.Error("want an error for a directory, got <nil>")
+	}
+	// Parent directories are not created.
+	if want, got := os.ErrNotExist, drv.WriteFile(filepath.Join("qux", "bar"), nil, 0o644); !errors.Is(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, drv fs.Driver, name, want string) {
+	t.Helper()
+	b, err := drv.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func stat(t *testing.T, drv fs.Driver, name string) fs.FileInfo {
+	t.Helper()
+	fi, err := drv.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fi
+}
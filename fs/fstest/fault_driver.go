@@ -0,0 +1,213 @@
+package fstest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+)
+
+// FaultDriver wraps a driver and makes calls to its methods fail according to Rules.
+// Calls that don't fail are passed through to Driver. It is safe for concurrent use,
+// as long as Driver is too.
+type FaultDriver struct {
+	Driver fs.Driver
+	Rules  []FaultRule
+
+	mu    sync.Mutex
+	calls map[int]int
+}
+
+// FaultRule makes calls to a driver's method fail.
+type FaultRule struct {
+	// Method is the name of the method, e.g. "Symlink".
+	Method string
+	// Path is a pattern, as in filepath.Match, matched against every path the method
+	// receives. Patterns without a path separator are matched against base names only.
+	// An empty pattern matches any path.
+	Path string
+	// N makes only the Nth matching call fail, counting from 1.
+	// If zero, every matching call fails.
+	N int
+	// Err is the underlying error, e.g. syscall.ENOSPC.
+	Err error
+}
+
+func (r FaultRule) match(method string, names []string) bool {
+	if r.Method != method {
+		return false
+	}
+	if r.Path == "" {
+		return true
+	}
+	for _, name := range names {
+		if !strings.ContainsRune(r.Path, filepath.Separator) {
+			name = filepath.Base(name)
+		}
+		if ok, _ := filepath.Match(r.Path, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Chmod fails according to rules for "Chmod" or calls Driver.Chmod.
+func (drv *FaultDriver) Chmod(name string, perm os.FileMode) error {
+	if err := drv.fault("Chmod", name); err != nil {
+		return err
+	}
+	return drv.Driver.Chmod(name, perm)
+}
+
+// Lchown fails according to rules for "Lchown" or calls Driver.Lchown.
+func (drv *FaultDriver) Lchown(name string, uid, gid int) error {
+	if err := drv.fault("Lchown", name); err != nil {
+		return err
+	}
+	return drv.Driver.Lchown(name, uid, gid)
+}
+
+// MkdirAll fails according to rules for "MkdirAll" or calls Driver.MkdirAll.
+// Like os.MkdirAll, missing directories are created one level at a time, each one
+// being a separate call for rules, so that it's possible to make it fail partway.
+func (drv *FaultDriver) MkdirAll(dirname string) error {
+	dirs, err := drv.missingDirs(dirname)
+	if err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		dirs = []string{dirname}
+	}
+	for _, dir := range dirs {
+		if err := drv.fault("MkdirAll", dir); err != nil {
+			return err
+		}
+		if err := drv.Driver.MkdirAll(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// missingDirs lists dirname and its parents that don't exist yet, from the outermost one.
+func (drv *FaultDriver) missingDirs(dirname string) ([]string, error) {
+	var dirs []string
+	for {
+		fi, err := drv.Driver.Stat(dirname)
+		if err != nil {
+			return nil, err
+		}
+		if fi.Exists() {
+			return dirs, nil
+		}
+		dirs = append([]string{dirname}, dirs...)
+		parent := filepath.Dir(dirname)
+		if parent == dirname {
+			return dirs, nil
+		}
+		dirname = parent
+	}
+}
+
+// ReadDir fails according to rules for "ReadDir" or calls Driver.ReadDir.
+func (drv *FaultDriver) ReadDir(dirname string) ([]fs.FileInfo, error) {
+	if err := drv.fault("ReadDir", dirname); err != nil {
+		return nil, err
+	}
+	return drv.Driver.ReadDir(dirname)
+}
+
+// ReadFile fails according to rules for "ReadFile" or calls Driver.ReadFile.
+func (drv *FaultDriver) ReadFile(filename string) ([]byte, error) {
+	if err := drv.fault("ReadFile", filename); err != nil {
+		return nil, err
+	}
+	return drv.Driver.ReadFile(filename)
+}
+
+// Readlink fails according to rules for "Readlink" or calls Driver.Readlink.
+func (drv *FaultDriver) Readlink(name string) (string, error) {
+	if err := drv.fault("Readlink", name); err != nil {
+		return "", err
+	}
+	return drv.Driver.Readlink(name)
+}
+
+// Remove fails according to rules for "Remove" or calls Driver.Remove.
+func (drv *FaultDriver) Remove(name string) error {
+	if err := drv.fault("Remove", name); err != nil {
+		return err
+	}
+	return drv.Driver.Remove(name)
+}
+
+// RemoveAll fails according to rules for "RemoveAll" or calls Driver.RemoveAll.
+func (drv *FaultDriver) RemoveAll(name string) error {
+	if err := drv.fault("RemoveAll", name); err != nil {
+		return err
+	}
+	return drv.Driver.RemoveAll(name)
+}
+
+// Rename fails according to rules for "Rename" or calls Driver.Rename.
+func (drv *FaultDriver) Rename(oldname, newname string) error {
+	if err := drv.fault("Rename", oldname, newname); err != nil {
+		return err
+	}
+	return drv.Driver.Rename(oldname, newname)
+}
+
+// Stat fails according to rules for "Stat" or calls Driver.Stat.
+func (drv *FaultDriver) Stat(filename string) (fs.FileInfo, error) {
+	if err := drv.fault("Stat", filename); err != nil {
+		return nil, err
+	}
+	return drv.Driver.Stat(filename)
+}
+
+// StatFollow fails according to rules for "StatFollow" or follows symlinks using Driver.
+func (drv *FaultDriver) StatFollow(filename string) (fs.FileInfo, error) {
+	if err := drv.fault("StatFollow", filename); err != nil {
+		return nil, err
+	}
+	return fs.New(drv.Driver).StatFollow(filename)
+}
+
+// Symlink fails according to rules for "Symlink" or calls Driver.Symlink.
+func (drv *FaultDriver) Symlink(oldname, newname string) error {
+	if err := drv.fault("Symlink", oldname, newname); err != nil {
+		return err
+	}
+	return drv.Driver.Symlink(oldname, newname)
+}
+
+// WriteFile fails according to rules for "WriteFile" or calls Driver.WriteFile.
+func (drv *FaultDriver) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	if err := drv.fault("WriteFile", filename); err != nil {
+		return err
+	}
+	return drv.Driver.WriteFile(filename, data, perm)
+}
+
+// fault counts calls matched by each rule and returns an error
+// for the first rule that makes the current call fail, if any.
+func (drv *FaultDriver) fault(method string, names ...string) error {
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+	if drv.calls == nil {
+		drv.calls = make(map[int]int, len(drv.Rules))
+	}
+	var err error
+	for i, r := range drv.Rules {
+		if !r.match(method, names) {
+			continue
+		}
+		drv.calls[i]++
+		if err == nil && (r.N == 0 || r.N == drv.calls[i]) {
+			err = &os.PathError{Op: strings.ToLower(method), Path: names[len(names)-1], Err: r.Err}
+		}
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package fstest_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+	"github.com/gbrlsnchs/pilgo/fs/fstest"
+	"github.com/google/go-cmp/cmp"
+)
+
+var _ fs.Driver = new(fstest.FaultDriver)
+
+func TestFaultDriver(t *testing.T) {
+	t.Run("Conformance", testFaultDriverConformance)
+	t.Run("Rules", testFaultDriverRules)
+	t.Run("MkdirAll", testFaultDriverMkdirAll)
+}
+
+func testFaultDriverConformance(t *testing.T) {
+	fstest.TestDriver(t, func(*testing.T) fs.Driver {
+		return &fstest.FaultDriver{Driver: new(fstest.InMemoryDriver)}
+	})
+}
+
+func testFaultDriverRules(t *testing.T) {
+	type call struct {
+		method string
+		name   string
+	}
+	calls := []call{
+		{"Symlink", "foo"},
+		{"Symlink", filepath.Join("dir", "bar")},
+		{"Symlink", "baz"},
+		{"WriteFile", filepath.Join("dir", "foo.yml")},
+		{"WriteFile", "bar.txt"},
+		{"WriteFile", "baz.yml"},
+	}
+	testCases := []struct {
+		name  string
+		rules []fstest.FaultRule
+		want  []error
+	}{
+		{
+			name:  "no rules",
+			rules: nil,
+			want:  []error{nil, nil, nil, nil, nil, nil},
+		},
+		{
+			name:  "every call",
+			rules: []fstest.FaultRule{{Method: "Symlink", Err: syscall.EACCES}},
+			want:  []error{syscall.EACCES, syscall.EACCES, syscall.EACCES, nil, nil, nil},
+		},
+		{
+			name:  "Nth call",
+			rules: []fstest.FaultRule{{Method: "Symlink", N: 2, Err: syscall.EACCES}},
+			want:  []error{nil, syscall.EACCES, nil, nil, nil, nil},
+		},
+		{
+			name:  "base name glob",
+			rules: []fstest.FaultRule{{Method: "WriteFile", Path: "*.yml", Err: syscall.ENOSPC}},
+			want:  []error{nil, nil, nil, syscall.ENOSPC, nil, syscall.ENOSPC},
+		},
+		{
+			name:  "full path glob",
+			rules: []fstest.FaultRule{{Method: "WriteFile", Path: filepath.Join("dir", "*"), Err: syscall.ENOSPC}},
+			want:  []error{nil, nil, nil, syscall.ENOSPC, nil, nil},
+		},
+		{
+			name:  "Nth call matching glob",
+			rules: []fstest.FaultRule{{Method: "WriteFile", Path: "*.yml", N: 2, Err: syscall.ENOSPC}},
+			want:  []error{nil, nil, nil, nil, nil, syscall.ENOSPC},
+		},
+		{
+			name: "multiple rules",
+			rules: []fstest.FaultRule{
+				{Method: "Symlink", Path: "ba*", Err: syscall.EACCES},
+				{Method: "WriteFile", N: 1, Err: syscall.ENOSPC},
+			},
+			want: []error{nil, syscall.EACCES, syscall.EACCES, syscall.ENOSPC, nil, nil},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			drv := &fstest.FaultDriver{
+				Driver: &fstest.SpyDriver{},
+				Rules:  tc.rules,
+			}
+			got := make([]error, len(calls))
+			for i, c := range calls {
+				var err error
+				switch c.method {
+				case "Symlink":
+					err = drv.Symlink("target", c.name)
+				case "WriteFile":
+					err = drv.WriteFile(c.name, nil, 0o644)
+				}
+				var perr *os.PathError
+				if errors.As(err, &perr) {
+					if want, got := c.name, perr.Path; got != want {
+						t.Errorf("want %q, got %q", want, got)
+					}
+					err = perr.Err
+				}
+				got[i] = err
+			}
+			if want := tc.want; !cmp.Equal(got, want, cmp.Comparer(errors.Is)) {
+				t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got, cmp.Comparer(errors.Is)))
+			}
+		})
+	}
+}
+
+func testFaultDriverMkdirAll(t *testing.T) {
+	inner := new(fstest.InMemoryDriver)
+	drv := &fstest.FaultDriver{
+		Driver: inner,
+		Rules:  []fstest.FaultRule{{Method: "MkdirAll", Path: "b", Err: syscall.ENOSPC}},
+	}
+	err := drv.MkdirAll(filepath.Join("a", "b", "c"))
+	var perr *os.PathError
+	if !errors.As(err, &perr) || !errors.Is(perr.Err, syscall.ENOSPC) {
+		t.Fatalf("want %v, got %v", syscall.ENOSPC, err)
+	}
+	if want, got := filepath.Join("a", "b"), perr.Path; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	for name, want := range map[string]bool{
+		"a":                     true,
+		filepath.Join("a", "b"): false,
+	} {
+		fi, err := inner.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fi.Exists(); got != want {
+			t.Errorf("want %q to exist: %t, got %t", name, want, got)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/gbrlsnchs/pilgo/fs"
 )
@@ -14,30 +15,61 @@ import (
 type createOpts int
 
 const (
-	mkdirOpt createOpts = 1 << iota
-	overwriteOpt
+	overwriteOpt createOpts = 1 << iota
 
 	absPrefix = "~"
+	// maxLinks is how many symlinks are followed before giving up.
+	maxLinks = 40
 )
 
 var (
-	// ErrNotExist means a file doesn't exist.
-	ErrNotExist = errors.New("file doesn't exist")
-	// ErrExist means a file already exists.
-	ErrExist = errors.New("file already exist")
+	// ErrNotExist means a file doesn't exist. It matches os.ErrNotExist.
+	ErrNotExist error = &driverError{"file doesn't exist", os.ErrNotExist}
+	// ErrExist means a file already exists. It matches os.ErrExist.
+	ErrExist error = &driverError{"file already exist", os.ErrExist}
 	// ErrNotDir means a file is not a directory.
 	ErrNotDir = errors.New("file is not a directory")
+	// ErrNotEmpty means a directory has children.
+	ErrNotEmpty = errors.New("directory not empty")
+	// ErrIsDir means a file is a directory.
+	ErrIsDir = errors.New("file is a directory")
+	// ErrNotLink means a file is not a symlink.
+	ErrNotLink = errors.New("file is not a symlink")
 
 	pathSep = string(filepath.Separator)
 )
 
 // InMemoryDriver is a synthetic file system that mimics
 // simple behaviors of a real file system in memory.
+//
+// Symlinks are resolved when they are parent directories in a path. Like in a real
+// file system, linknames are stored as they're given to Symlink and relative ones are
+// resolved from the directory containing the symlink, while absolute ones, which are
+// built with AbsPath, are resolved from the root of the file system.
 type InMemoryDriver struct {
 	CurrentDir string
 	Files      map[string]File
 }
 
+// Chmod simulates changing the permission of a file.
+func (drv *InMemoryDriver) Chmod(name string, perm os.FileMode) error {
+	name = drv.resolvePath(name)
+	return drv.update(name, func(f *File) { f.Perm = perm })
+}
+
+// Lchown simulates changing the owner of a file. A negative uid or gid is left unchanged.
+func (drv *InMemoryDriver) Lchown(name string, uid, gid int) error {
+	name = drv.resolvePath(name)
+	return drv.update(name, func(f *File) {
+		if uid >= 0 {
+			f.UID = uid
+		}
+		if gid >= 0 {
+			f.GID = gid
+		}
+	})
+}
+
 // MkdirAll simulates the creation of a directory. It also creates the parents of
 // such directory, if needed.
 func (drv *InMemoryDriver) MkdirAll(dirname string) error {
@@ -50,6 +82,9 @@ func (drv *InMemoryDriver) MkdirAll(dirname string) error {
 func (drv *InMemoryDriver) ReadDir(dirname string) ([]fs.FileInfo, error) {
 	dirname = drv.resolvePath(dirname)
 	fstat, err := drv.find(dirname)
+	if err == nil {
+		fstat, err = drv.follow(dirname, fstat)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -66,16 +101,93 @@ func (drv *InMemoryDriver) ReadDir(dirname string) ([]fs.FileInfo, error) {
 }
 
 // ReadFile simulates a file read. It returns data associated with a file or an error
-// if the file can't be found.
+// if the file can't be found or is a directory.
 func (drv *InMemoryDriver) ReadFile(filename string) ([]byte, error) {
 	filename = drv.resolvePath(filename)
 	fstat, err := drv.find(filename)
+	if err == nil {
+		fstat, err = drv.follow(filename, fstat)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if fstat.IsDir() {
+		return nil, fmt.Errorf("fstest: %s: %w", filename, ErrIsDir)
+	}
 	return fstat.File.Data, nil
 }
 
+// Readlink simulates reading the name of the file a symlink points to.
+func (drv *InMemoryDriver) Readlink(name string) (string, error) {
+	name = drv.resolvePath(name)
+	fstat, err := drv.find(name)
+	if err != nil {
+		return "", err
+	}
+	if fstat.Linkname() == "" {
+		return "", fmt.Errorf("fstest: %s: %w", name, ErrNotLink)
+	}
+	return fstat.Linkname(), nil
+}
+
+// Remove simulates the removal of a file or an empty directory.
+func (drv *InMemoryDriver) Remove(name string) error {
+	name = drv.resolvePath(name)
+	files, base, err := drv.parent(name)
+	if err != nil {
+		return err
+	}
+	f, ok := files[base]
+	if !ok {
+		return fmt.Errorf("fstest: %s: %w", name, ErrNotExist)
+	}
+	if len(f.Children) > 0 {
+		return fmt.Errorf("fstest: %s: %w", name, ErrNotEmpty)
+	}
+	delete(files, base)
+	return nil
+}
+
+// RemoveAll simulates the removal of a file and its children, if any.
+// It doesn't return an error if the file doesn't exist.
+func (drv *InMemoryDriver) RemoveAll(name string) error {
+	name = drv.resolvePath(name)
+	files, base, err := drv.parent(name)
+	if errors.Is(err, ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	delete(files, base)
+	return nil
+}
+
+// Rename simulates moving oldname to newname. It replaces newname
+// if it exists, unless it is a directory.
+func (drv *InMemoryDriver) Rename(oldname, newname string) error {
+	oldname = drv.resolvePath(oldname)
+	newname = drv.resolvePath(newname)
+	oldFiles, oldBase, err := drv.parent(oldname)
+	if err != nil {
+		return err
+	}
+	f, ok := oldFiles[oldBase]
+	if !ok {
+		return fmt.Errorf("fstest: %s: %w", oldname, ErrNotExist)
+	}
+	newFiles, newBase, err := drv.parent(newname)
+	if err != nil {
+		return err
+	}
+	if dst, ok := newFiles[newBase]; ok && dst.Children != nil {
+		return fmt.Errorf("fstest: %s: %w", newname, ErrExist)
+	}
+	delete(oldFiles, oldBase)
+	newFiles[newBase] = f
+	return nil
+}
+
 // Stat simulates reading information about filename. If filename doesn't exist, instead of
 // returning an error, Stat returns an empty FileStat object.
 func (drv *InMemoryDriver) Stat(filename string) (fs.FileInfo, error) {
@@ -87,10 +199,22 @@ func (drv *InMemoryDriver) Stat(filename string) (fs.FileInfo, error) {
 	return fstat, err
 }
 
+// StatFollow works like Stat, but follows symlinks.
+func (drv *InMemoryDriver) StatFollow(filename string) (fs.FileInfo, error) {
+	filename = drv.resolvePath(filename)
+	fstat, err := drv.find(filename)
+	if err == nil {
+		fstat, err = drv.follow(filename, fstat)
+	}
+	if errors.Is(err, ErrNotExist) {
+		return FileStat{}, nil
+	}
+	return fstat, err
+}
+
 // Symlink simulates a symlink creation. It creates a symlink if newname doesn't exist,
 // or return an error instead.
 func (drv *InMemoryDriver) Symlink(oldname, newname string) error {
-	oldname = drv.resolvePath(oldname)
 	newname = drv.resolvePath(newname)
 	f := File{
 		Linkname: oldname,
@@ -98,7 +222,7 @@ func (drv *InMemoryDriver) Symlink(oldname, newname string) error {
 		Data:     nil,
 		Children: nil,
 	}
-	return drv.create(newname, f, mkdirOpt)
+	return drv.create(newname, f, 0)
 }
 
 // WriteFile simulates a file write by associating data and perm with filename.
@@ -130,14 +254,16 @@ func (drv *InMemoryDriver) create(filename string, f File, opts createOpts) erro
 	dir, file := filepath.Split(filename)
 	if dir != "" {
 		dir = strings.TrimSuffix(dir, pathSep)
-		fstatFn := drv.find
-		if opts&mkdirOpt != 0 {
-			fstatFn = drv.mkdirAll
+		fstat, err := drv.find(dir)
+		if err == nil {
+			fstat, err = drv.follow(dir, fstat)
 		}
-		fstat, err := fstatFn(dir)
 		if err != nil {
 			return err
 		}
+		if !fstat.IsDir() {
+			return fmt.Errorf("fstest: %s: %w", dir, ErrNotDir)
+		}
 		parent = fstat.File.Children
 	}
 	if exists {
@@ -147,13 +273,95 @@ func (drv *InMemoryDriver) create(filename string, f File, opts createOpts) erro
 	return nil
 }
 
+// follow resolves fstat, which is the file at filename, to the file it points to
+// if it's a symlink. The resolved file keeps the original name.
+func (drv *InMemoryDriver) follow(filename string, fstat FileStat) (FileStat, error) {
+	name := filename
+	for i := 0; fstat.Linkname() != ""; i++ {
+		if i == maxLinks {
+			return FileStat{}, fmt.Errorf("fstest: %s: %w", filename, fs.ErrTooManyLinks)
+		}
+		name = linkTarget(name, fstat.Linkname())
+		target, err := drv.find(name)
+		if err != nil {
+			return FileStat{}, err
+		}
+		fstat.File = target.File
+	}
+	return fstat, nil
+}
+
+// linkTarget returns the path of the file that linkname, set for the symlink at name, points to.
+func linkTarget(name, linkname string) string {
+	if strings.HasPrefix(linkname, absPrefix) {
+		return linkname[len(absPrefix):]
+	}
+	return filepath.Join(filepath.Dir(name), linkname)
+}
+
+// parent returns the children of the directory containing filename
+// and the base name of filename.
+func (drv *InMemoryDriver) parent(filename string) (map[string]File, string, error) {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		if drv.Files == nil {
+			drv.Files = make(map[string]File, 1)
+		}
+		return drv.Files, base, nil
+	}
+	dir = strings.TrimSuffix(dir, pathSep)
+	fstat, err := drv.find(dir)
+	if err == nil {
+		fstat, err = drv.follow(dir, fstat)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if !fstat.IsDir() {
+		return nil, "", ErrNotDir
+	}
+	return fstat.File.Children, base, nil
+}
+
+// update applies fn to filename and stores the result.
+func (drv *InMemoryDriver) update(filename string, fn func(*File)) error {
+	files, base, err := drv.parent(filename)
+	if err != nil {
+		return err
+	}
+	f, ok := files[base]
+	if !ok {
+		return fmt.Errorf("fstest: %s: %w", filename, ErrNotExist)
+	}
+	fn(&f)
+	files[base] = f
+	return nil
+}
+
+// find looks up filename, resolving symlinks that are parent directories
+// of filename. If filename itself is a symlink, it is not resolved.
 func (drv *InMemoryDriver) find(filename string) (FileStat, error) {
+	return drv.lookup(filename, filename, 0)
+}
+
+func (drv *InMemoryDriver) lookup(filename, resolved string, links int) (FileStat, error) {
 	var (
 		fstat FileStat
 		files = drv.Files
-		paths = strings.Split(filename, pathSep)
+		paths = strings.Split(resolved, pathSep)
 	)
-	for _, p := range paths {
+	for i, p := range paths {
+		if linkname := fstat.Linkname(); i > 0 && linkname != "" {
+			if links == maxLinks {
+				return FileStat{}, fmt.Errorf("fstest: %s: %w", filename, fs.ErrTooManyLinks)
+			}
+			target := linkTarget(filepath.Join(paths[:i]...), linkname)
+			resolved = filepath.Join(append([]string{target}, paths[i:]...)...)
+			return drv.lookup(filename, resolved, links+1)
+		}
+		if i > 0 && !fstat.IsDir() {
+			return FileStat{}, fmt.Errorf("fstest: %s: %w", filename, ErrNotDir)
+		}
 		if f, ok := files[p]; ok {
 			files = f.Children
 			fstat = FileStat{p, f}
@@ -172,17 +380,26 @@ func (drv *InMemoryDriver) mkdirAll(dirname string) (FileStat, error) {
 		fstat FileStat
 		files = drv.Files
 		paths = strings.Split(dirname, pathSep)
+		cur   string
 	)
 	for _, p := range paths {
 		if p == "" {
 			continue
 		}
+		cur = filepath.Join(cur, p)
 		f, ok := files[p]
 		if ok {
-			fstat := FileStat{p, f}
+			fstat, err := drv.follow(cur, FileStat{p, f})
+			if errors.Is(err, ErrNotExist) { // dangling symlink
+				return FileStat{}, ErrExist
+			}
+			if err != nil {
+				return FileStat{}, err
+			}
 			if !fstat.IsDir() {
 				return FileStat{}, ErrExist
 			}
+			f = fstat.File
 		} else {
 			f = File{
 				Perm:     os.ModePerm,
@@ -210,6 +427,10 @@ type File struct {
 	Linkname string
 	Data     []byte
 	Children map[string]File
+	UID, GID int
+	// Type holds the type bits of special files, like os.ModeNamedPipe.
+	Type    os.FileMode
+	ModTime time.Time
 }
 
 // AbsPath returns an absolute path with the proper prefix.
@@ -234,12 +455,48 @@ func (f FileStat) Exists() bool { return f.Label != "" }
 // IsDir returns whether a file is a directory.
 func (f FileStat) IsDir() bool { return f.File.Children != nil }
 
+// IsSymlink returns whether a file is a symlink.
+func (f FileStat) IsSymlink() bool { return f.File.Linkname != "" }
+
 // Linkname returns the name of a file a link is pointing to.
 func (f FileStat) Linkname() string { return f.File.Linkname }
 
 // Perm returns a file's associated permission.
 func (f FileStat) Perm() os.FileMode { return f.File.Perm }
 
+// Mode returns a file's type bits combined with its permission.
+func (f FileStat) Mode() os.FileMode {
+	mode := f.File.Type&os.ModeType | f.File.Perm.Perm()
+	if f.IsDir() {
+		mode |= os.ModeDir
+	}
+	if f.IsSymlink() {
+		mode |= os.ModeSymlink
+	}
+	return mode
+}
+
+// ModTime returns a file's modification time.
+func (f FileStat) ModTime() time.Time { return f.File.ModTime }
+
+// Size returns the length of a file's data or, for symlinks, of its linkname.
+func (f FileStat) Size() int64 {
+	if f.IsSymlink() {
+		return int64(len(f.File.Linkname))
+	}
+	return int64(len(f.File.Data))
+}
+
+type driverError struct {
+	msg    string
+	target error
+}
+
+func (err *driverError) Error() string { return err.msg }
+
+// Is makes driver errors compatible with errors from the os package.
+func (err *driverError) Is(target error) bool { return target == err.target }
+
 type sortedFiles []fs.FileInfo
 
 func (sf sortedFiles) Len() int           { return len(sf) }
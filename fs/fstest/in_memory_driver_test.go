@@ -23,6 +23,7 @@ func TestAbsPath(t *testing.T) {
 }
 
 func TestInMemoryDriver(t *testing.T) {
+	t.Run("Conformance", testInMemoryDriverConformance)
 	t.Run("MkdirAll", testInMemoryDriverMkdirAll)
 	t.Run("ReadDir", testInMemoryDriverReadDir)
 	t.Run("ReadFile", testInMemoryDriverReadFile)
@@ -31,6 +32,12 @@ func TestInMemoryDriver(t *testing.T) {
 	t.Run("WriteFile", testInMemoryDriverWriteFile)
 }
 
+func testInMemoryDriverConformance(t *testing.T) {
+	fstest.TestDriver(t, func(*testing.T) fs.Driver {
+		return new(fstest.InMemoryDriver)
+	})
+}
+
 func testInMemoryDriverMkdirAll(t *testing.T) {
 	testCases := []struct {
 		drv     fstest.InMemoryDriver
@@ -822,7 +829,8 @@ func testInMemoryDriverSymlink(t *testing.T) {
 						},
 					},
 					"bar": {
-						Linkname: filepath.Join("foo", "bar"),
+						// Linknames are stored as they're given.
+						Linkname: "bar",
 						Perm:     os.ModePerm,
 						Data:     nil,
 						Children: nil,
@@ -869,7 +877,7 @@ func testInMemoryDriverSymlink(t *testing.T) {
 						},
 					},
 					"bar": {
-						Linkname: filepath.Join("foo", "bar"),
+						Linkname: fstest.AbsPath("foo", "bar"),
 						Perm:     os.ModePerm,
 						Data:     nil,
 						Children: nil,
@@ -3,12 +3,19 @@ package fstest
 import (
 	"os"
 	"reflect"
+	"time"
 
 	"github.com/gbrlsnchs/pilgo/fs"
 )
 
 // SpyDriver is a stub and spy implementation of a file system's functionalities.
 type SpyDriver struct {
+	// Chmod
+	ChmodErr map[string]error
+
+	// Lchown
+	LchownErr map[string]error
+
 	// MkdirAll
 	MkdirAllErr map[string]error
 
@@ -20,10 +27,27 @@ type SpyDriver struct {
 	ReadFileReturn map[string][]byte
 	ReadFileErr    map[string]error
 
+	// Readlink
+	ReadlinkReturn map[string]string
+	ReadlinkErr    map[string]error
+
+	// Remove
+	RemoveErr map[string]error
+
+	// RemoveAll
+	RemoveAllErr map[string]error
+
+	// Rename
+	RenameErr map[string]error
+
 	// Stat
 	StatReturn map[string]fs.FileInfo
 	StatErr    map[string]error
 
+	// StatFollow
+	StatFollowReturn map[string]fs.FileInfo
+	StatFollowErr    map[string]error
+
 	// Symlink
 	SymlinkErr map[string]error
 
@@ -44,6 +68,18 @@ func (drv *SpyDriver) HasBeenCalled(fn Method) (bool, CallStack) {
 	return ok, args
 }
 
+// Chmod returns a stub of a permission change.
+func (drv *SpyDriver) Chmod(name string, perm os.FileMode) error {
+	defer drv.setHasBeenCalled(drv.Chmod, name, perm)
+	return drv.ChmodErr[name]
+}
+
+// Lchown returns a stub of an owner change.
+func (drv *SpyDriver) Lchown(name string, uid, gid int) error {
+	defer drv.setHasBeenCalled(drv.Lchown, name, uid, gid)
+	return drv.LchownErr[name]
+}
+
 // MkdirAll returns a stub of directory creation.
 func (drv *SpyDriver) MkdirAll(dirname string) error {
 	defer drv.setHasBeenCalled(drv.MkdirAll, dirname)
@@ -60,11 +96,41 @@ func (drv *SpyDriver) ReadFile(filename string) ([]byte, error) {
 	return drv.ReadFileReturn[filename], drv.ReadFileErr[filename]
 }
 
+// Readlink returns a stub of a symlink read.
+func (drv *SpyDriver) Readlink(name string) (string, error) {
+	defer drv.setHasBeenCalled(drv.Readlink, name)
+	return drv.ReadlinkReturn[name], drv.ReadlinkErr[name]
+}
+
+// Remove returns a stub of a file removal.
+func (drv *SpyDriver) Remove(name string) error {
+	defer drv.setHasBeenCalled(drv.Remove, name)
+	return drv.RemoveErr[name]
+}
+
+// RemoveAll returns a stub of a recursive file removal.
+func (drv *SpyDriver) RemoveAll(name string) error {
+	defer drv.setHasBeenCalled(drv.RemoveAll, name)
+	return drv.RemoveAllErr[name]
+}
+
+// Rename returns a stub of a file move.
+func (drv *SpyDriver) Rename(oldname, newname string) error {
+	defer drv.setHasBeenCalled(drv.Rename, oldname, newname)
+	return drv.RenameErr[oldname]
+}
+
 func (drv *SpyDriver) Stat(filename string) (fs.FileInfo, error) {
 	defer drv.setHasBeenCalled(drv.Stat, filename)
 	return drv.StatReturn[filename], drv.StatErr[filename]
 }
 
+// StatFollow returns a stub of reading information about a file while following symlinks.
+func (drv *SpyDriver) StatFollow(filename string) (fs.FileInfo, error) {
+	defer drv.setHasBeenCalled(drv.StatFollow, filename)
+	return drv.StatFollowReturn[filename], drv.StatFollowErr[filename]
+}
+
 // Symlink returns a stub of a symlink creation.
 func (drv *SpyDriver) Symlink(oldname, newname string) error {
 	defer drv.setHasBeenCalled(drv.Symlink, oldname, newname)
@@ -85,15 +151,23 @@ func (drv *SpyDriver) setHasBeenCalled(fn Method, args ...Arg) {
 }
 
 type StubFile struct {
-	NameReturn     string
-	ExistsReturn   bool
-	IsDirReturn    bool
-	LinknameReturn string
-	PermReturn     os.FileMode
-}
-
-func (fi StubFile) Name() string      { return fi.NameReturn }
-func (fi StubFile) Exists() bool      { return fi.ExistsReturn }
-func (fi StubFile) IsDir() bool       { return fi.IsDirReturn }
-func (fi StubFile) Linkname() string  { return fi.LinknameReturn }
-func (fi StubFile) Perm() os.FileMode { return fi.PermReturn }
+	NameReturn      string
+	ExistsReturn    bool
+	IsDirReturn     bool
+	IsSymlinkReturn bool
+	LinknameReturn  string
+	PermReturn      os.FileMode
+	ModeReturn      os.FileMode
+	ModTimeReturn   time.Time
+	SizeReturn      int64
+}
+
+func (fi StubFile) Name() string       { return fi.NameReturn }
+func (fi StubFile) Exists() bool       { return fi.ExistsReturn }
+func (fi StubFile) IsDir() bool        { return fi.IsDirReturn }
+func (fi StubFile) IsSymlink() bool    { return fi.IsSymlinkReturn }
+func (fi StubFile) Linkname() string   { return fi.LinknameReturn }
+func (fi StubFile) Perm() os.FileMode  { return fi.PermReturn }
+func (fi StubFile) Mode() os.FileMode  { return fi.ModeReturn }
+func (fi StubFile) ModTime() time.Time { return fi.ModTimeReturn }
+func (fi StubFile) Size() int64        { return fi.SizeReturn }
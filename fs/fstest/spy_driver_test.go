@@ -13,14 +13,90 @@ import (
 var _ fs.Driver = new(fstest.SpyDriver)
 
 func TestSpyDriver(t *testing.T) {
+	t.Run("Chmod", testSpyDriverChmod)
+	t.Run("Lchown", testSpyDriverLchown)
 	t.Run("MkdirAll", testSpyDriverMkdirAll)
 	t.Run("ReadDir", testSpyDriverReadDir)
 	t.Run("ReadFile", testSpyDriverReadFile)
+	t.Run("Readlink", testSpyDriverReadlink)
+	t.Run("Remove", testSpyDriverRemove)
+	t.Run("RemoveAll", testSpyDriverRemoveAll)
+	t.Run("Rename", testSpyDriverRename)
 	t.Run("Stat", testSpyDriverStat)
 	t.Run("Symlink", testSpyDriverSymlink)
 	t.Run("WriteFile", testSpyDriverWriteFile)
 }
 
+func testSpyDriverChmod(t *testing.T) {
+	errChmod := errors.New("Chmod")
+	testCases := []struct {
+		drv  fstest.SpyDriver
+		args fstest.Args
+		err  error
+	}{
+		{
+			drv: fstest.SpyDriver{
+				ChmodErr: map[string]error{
+					"foo": errChmod,
+				},
+			},
+			args: fstest.Args{"foo", os.FileMode(0o600)},
+			err:  errChmod,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run("foo", func(t *testing.T) {
+			err := tc.drv.Chmod(tc.args[0].(string), tc.args[1].(os.FileMode))
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			hasBeenCalled, args := tc.drv.HasBeenCalled(tc.drv.Chmod)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{tc.args}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func testSpyDriverLchown(t *testing.T) {
+	errLchown := errors.New("Lchown")
+	testCases := []struct {
+		drv  fstest.SpyDriver
+		args fstest.Args
+		err  error
+	}{
+		{
+			drv: fstest.SpyDriver{
+				LchownErr: map[string]error{
+					"foo": errLchown,
+				},
+			},
+			args: fstest.Args{"foo", 1000, 1000},
+			err:  errLchown,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run("foo", func(t *testing.T) {
+			err := tc.drv.Lchown(tc.args[0].(string), tc.args[1].(int), tc.args[2].(int))
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			hasBeenCalled, args := tc.drv.HasBeenCalled(tc.drv.Lchown)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{tc.args}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
 func testSpyDriverMkdirAll(t *testing.T) {
 	errMkdirAll := errors.New("MkdirAll")
 	testCases := []struct {
@@ -265,6 +341,163 @@ func testSpyDriverReadFile(t *testing.T) {
 	}
 }
 
+func testSpyDriverReadlink(t *testing.T) {
+	errReadlink := errors.New("Readlink")
+	testCases := []struct {
+		drv  fstest.SpyDriver
+		name string
+		want string
+		err  error
+	}{
+		{
+			drv: fstest.SpyDriver{
+				ReadlinkReturn: map[string]string{
+					"foo": "bar",
+				},
+				ReadlinkErr: nil,
+			},
+			name: "foo",
+			want: "bar",
+			err:  nil,
+		},
+		{
+			drv: fstest.SpyDriver{
+				ReadlinkReturn: nil,
+				ReadlinkErr: map[string]error{
+					"foo": errReadlink,
+				},
+			},
+			name: "foo",
+			want: "",
+			err:  errReadlink,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			linkname, err := tc.drv.Readlink(tc.name)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if want, got := tc.want, linkname; got != want {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+			hasBeenCalled, args := tc.drv.HasBeenCalled(tc.drv.Readlink)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{fstest.Args{tc.name}}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func testSpyDriverRemove(t *testing.T) {
+	errRemove := errors.New("Remove")
+	testCases := []struct {
+		drv  fstest.SpyDriver
+		args fstest.Args
+		err  error
+	}{
+		{
+			drv: fstest.SpyDriver{
+				RemoveErr: map[string]error{
+					"foo": errRemove,
+				},
+			},
+			args: fstest.Args{"foo"},
+			err:  errRemove,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run("foo", func(t *testing.T) {
+			err := tc.drv.Remove(tc.args[0].(string))
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			hasBeenCalled, args := tc.drv.HasBeenCalled(tc.drv.Remove)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{tc.args}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func testSpyDriverRemoveAll(t *testing.T) {
+	errRemoveAll := errors.New("RemoveAll")
+	testCases := []struct {
+		drv  fstest.SpyDriver
+		args fstest.Args
+		err  error
+	}{
+		{
+			drv: fstest.SpyDriver{
+				RemoveAllErr: map[string]error{
+					"foo": errRemoveAll,
+				},
+			},
+			args: fstest.Args{"foo"},
+			err:  errRemoveAll,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run("foo", func(t *testing.T) {
+			err := tc.drv.RemoveAll(tc.args[0].(string))
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			hasBeenCalled, args := tc.drv.HasBeenCalled(tc.drv.RemoveAll)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{tc.args}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func testSpyDriverRename(t *testing.T) {
+	errRename := errors.New("Rename")
+	testCases := []struct {
+		drv  fstest.SpyDriver
+		args fstest.Args
+		err  error
+	}{
+		{
+			drv: fstest.SpyDriver{
+				RenameErr: map[string]error{
+					"foo": errRename,
+				},
+			},
+			args: fstest.Args{"foo", "bar"},
+			err:  errRename,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run("foo", func(t *testing.T) {
+			err := tc.drv.Rename(tc.args[0].(string), tc.args[1].(string))
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			hasBeenCalled, args := tc.drv.HasBeenCalled(tc.drv.Rename)
+			if want, got := true, hasBeenCalled; got != want {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			callstack := fstest.CallStack{tc.args}
+			if want, got := callstack, args; !cmp.Equal(got, want) {
+				t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
 func testSpyDriverStat(t *testing.T) {
 	errStat := errors.New("Stat")
 	testCases := []struct {
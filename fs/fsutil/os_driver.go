@@ -4,18 +4,24 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/andybalholm/crlf"
 	"github.com/gbrlsnchs/pilgo/fs"
 	"golang.org/x/text/transform"
 )
 
-// NOTE(gbrlsnchs): Declaring this variable here might prevent concurrent file reads, since
-// this is a stateful transformer used between reads and reset at the beginning of reads.
-var normalize = new(crlf.Normalize)
+// OSDriver is the driver for a concrete file system. It is safe for concurrent use.
+type OSDriver struct {
+	// PreserveCRLF disables transforming CRLF newlines into LF when reading files,
+	// which is needed in order to read binary files as they are.
+	PreserveCRLF bool
+}
 
-// OSDriver is the driver for a concrete file system.
-type OSDriver struct{}
+// Chmod changes the permission of a file.
+func (OSDriver) Chmod(name string, perm os.FileMode) error {
+	return os.Chmod(name, perm)
+}
 
 // MkdirAll creates directories recursively or is a NOP when they already exist.
 func (OSDriver) MkdirAll(dirname string) error {
@@ -33,15 +39,9 @@ func (OSDriver) ReadDir(dirname string) ([]fs.FileInfo, error) {
 	}
 	names := make([]fs.FileInfo, len(files))
 	for i, fi := range files {
-		mode := fi.Mode()
-		info := fileInfo{
-			name:   fi.Name(),
-			exists: true,
-			isDir:  fi.IsDir(),
-			perm:   mode.Perm(),
-		}
+		info := newFileInfo(fi)
 		// TODO(gbrlsnchs): add test cases
-		if mode&os.ModeSymlink != 0 {
+		if info.IsSymlink() {
 			filename := filepath.Join(dirname, info.name)
 			if info.linkname, err = os.Readlink(filename); err != nil {
 				return nil, err
@@ -53,33 +53,66 @@ func (OSDriver) ReadDir(dirname string) ([]fs.FileInfo, error) {
 }
 
 // ReadFile returns the content of filename.
-// It always transforms CRLF newlines into LF only.
-func (OSDriver) ReadFile(filename string) ([]byte, error) {
+// Unless CRLF newlines are preserved, it transforms them into LF only.
+func (drv OSDriver) ReadFile(filename string) ([]byte, error) {
+	if drv.PreserveCRLF {
+		return ioutil.ReadFile(filename)
+	}
 	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	return ioutil.ReadAll(transform.NewReader(f, normalize))
+	// The transformer is stateful, so every read needs its own.
+	return ioutil.ReadAll(transform.NewReader(f, new(crlf.Normalize)))
+}
+
+// Readlink returns the name of the file a symlink points to.
+func (OSDriver) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// Remove removes a file or an empty directory.
+func (OSDriver) Remove(name string) error {
+	return os.Remove(name)
 }
 
-// Stat returns real information about a file.
+// RemoveAll removes a file and its children, if any.
+func (OSDriver) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+// Rename moves oldname to newname, replacing newname if it's not a directory.
+func (OSDriver) Rename(oldname, newname string) error {
+	return os.Rename(oldname, newname)
+}
+
+// Stat returns real information about a file. It doesn't follow symlinks.
 func (OSDriver) Stat(filename string) (fs.FileInfo, error) {
-	fi, err := os.Lstat(filename)
+	return stat(filename, os.Lstat)
+}
+
+// StatFollow returns real information about a file, following symlinks.
+func (OSDriver) StatFollow(filename string) (fs.FileInfo, error) {
+	return stat(filename, os.Stat)
+}
+
+// Symlink creates a symbolic link newname of oldname.
+func (OSDriver) Symlink(oldname, newname string) error {
+	// TODO(gbrlsnchs): use renameio.Symlink to replace newname, if desired.
+	return os.Symlink(oldname, newname)
+}
+
+func stat(filename string, statFn func(string) (os.FileInfo, error)) (fs.FileInfo, error) {
+	fi, err := statFn(filename)
 	if err != nil {
 		if !os.IsNotExist(err) {
 			return nil, err
 		}
 		return fileInfo{exists: false}, nil
 	}
-	mode := fi.Mode()
-	info := fileInfo{
-		name:   fi.Name(),
-		exists: true,
-		isDir:  fi.IsDir(),
-		perm:   mode.Perm(),
-	}
-	if mode&os.ModeSymlink != 0 {
+	info := newFileInfo(fi)
+	if info.IsSymlink() {
 		if info.linkname, err = os.Readlink(filename); err != nil {
 			return nil, err
 		}
@@ -87,22 +120,31 @@ func (OSDriver) Stat(filename string) (fs.FileInfo, error) {
 	return info, nil
 }
 
-// Symlink creates a symbolic link newname of oldname.
-func (OSDriver) Symlink(oldname, newname string) error {
-	// TODO(gbrlsnchs): use renameio.Symlink to replace newname, if desired.
-	return os.Symlink(oldname, newname)
-}
-
 type fileInfo struct {
 	name     string
 	exists   bool
-	isDir    bool
 	linkname string
-	perm     os.FileMode
+	mode     os.FileMode
+	modTime  time.Time
+	size     int64
+}
+
+func newFileInfo(fi os.FileInfo) fileInfo {
+	return fileInfo{
+		name:    fi.Name(),
+		exists:  true,
+		mode:    fi.Mode(),
+		modTime: fi.ModTime(),
+		size:    fi.Size(),
+	}
 }
 
-func (fi fileInfo) Name() string      { return fi.name }
-func (fi fileInfo) Exists() bool      { return fi.exists }
-func (fi fileInfo) IsDir() bool       { return fi.isDir }
-func (fi fileInfo) Linkname() string  { return fi.linkname }
-func (fi fileInfo) Perm() os.FileMode { return fi.perm }
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Exists() bool       { return fi.exists }
+func (fi fileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi fileInfo) IsSymlink() bool    { return fi.mode&os.ModeSymlink != 0 }
+func (fi fileInfo) Linkname() string   { return fi.linkname }
+func (fi fileInfo) Perm() os.FileMode  { return fi.mode.Perm() }
+func (fi fileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi fileInfo) Size() int64        { return fi.size }
@@ -1,14 +1,21 @@
 package fsutil_test
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/andybalholm/crlf"
+	"github.com/gbrlsnchs/pilgo/fs"
+	"github.com/gbrlsnchs/pilgo/fs/fstest"
 	"github.com/gbrlsnchs/pilgo/fs/fsutil"
 	"golang.org/x/text/transform"
 )
@@ -32,14 +39,43 @@ var (
 )
 
 func TestOSDriver(t *testing.T) {
+	t.Run("Conformance", testOSDriverConformance)
 	t.Run("MkdirAll", testOSDriverMkdirAll)
 	t.Run("ReadDir", testOSDriverReadDir)
 	t.Run("ReadFile", testOSDriverReadFile)
+	t.Run("ReadFileConcurrent", testOSDriverReadFileConcurrent)
+	t.Run("ReadFilePreserveCRLF", testOSDriverReadFilePreserveCRLF)
 	t.Run("Stat", testOSDriverStat)
 	t.Run("Symlink", testOSDriverSymlink)
 	t.Run("WriteFile", testOSDriverWriteFile)
 }
 
+func testOSDriverConformance(t *testing.T) {
+	fstest.TestDriver(t, func(t *testing.T) fs.Driver {
+		chdirTemp(t)
+		return fsutil.OSDriver{}
+	})
+}
+
+// chdirTemp changes the working directory to a new temporary directory and returns it.
+// The previous working directory is restored when t finishes.
+func chdirTemp(t *testing.T) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Error(err)
+		}
+	})
+	return dir
+}
+
 func testOSDriverMkdirAll(t *testing.T) {
 	testCases := []struct {
 		dirname string
@@ -180,6 +216,79 @@ func testOSDriverReadFile(t *testing.T) {
 	}
 }
 
+func testOSDriverReadFileConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pilgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	const n = 64
+	for i := 0; i < n; i++ {
+		// Long enough to need more than one call to the transformer.
+		data := strings.Repeat(fmt.Sprintf("line %d\r\n", i), 4096)
+		filename := filepath.Join(dir, strconv.Itoa(i))
+		if err := ioutil.WriteFile(filename, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var (
+		drv  fsutil.OSDriver
+		wg   sync.WaitGroup
+		errs = make([]error, n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b, err := drv.ReadFile(filepath.Join(dir, strconv.Itoa(i)))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			if want, got := strings.Repeat(fmt.Sprintf("line %d\n", i), 4096), string(b); got != want {
+				errs[i] = fmt.Errorf("file %d: want %d bytes, got %d", i, len(want), len(got))
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func testOSDriverReadFilePreserveCRLF(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pilgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "binary")
+	data := []byte{0x00, '\r', '\n', 0xff, '\r', '\n'}
+	if err := ioutil.WriteFile(filename, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		drv  fsutil.OSDriver
+		want []byte
+	}{
+		{fsutil.OSDriver{}, []byte{0x00, '\n', 0xff, '\n'}},
+		{fsutil.OSDriver{PreserveCRLF: true}, data},
+	}
+	for _, tc := range testCases {
+		t.Run(strconv.FormatBool(tc.drv.PreserveCRLF), func(t *testing.T) {
+			b, err := tc.drv.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, got := tc.want, b; !bytes.Equal(got, want) {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+		})
+	}
+}
+
 func testOSDriverStat(t *testing.T) {
 	testCases := []struct {
 		filename string
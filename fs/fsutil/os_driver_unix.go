@@ -8,6 +8,11 @@ import (
 	"github.com/google/renameio"
 )
 
+// Lchown changes the owner of a file without following symlinks.
+func (OSDriver) Lchown(name string, uid, gid int) error {
+	return os.Lchown(name, uid, gid)
+}
+
 // WriteFile writes data to filename atomically with permission perm.
 func (OSDriver) WriteFile(filename string, data []byte, perm os.FileMode) error {
 	return renameio.WriteFile(filename, data, perm)
@@ -0,0 +1,47 @@
+// +build !windows
+
+package fsutil_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+	"github.com/gbrlsnchs/pilgo/fs/fsutil"
+)
+
+func TestOSDriverSpecialFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pilgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "fifo")
+	if err := syscall.Mkfifo(filename, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var drv fsutil.OSDriver
+	fi, err := drv.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := os.ModeNamedPipe, fi.Mode()&os.ModeType; got != want {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if want, got := true, fs.IsSpecial(fi); got != want {
+		t.Errorf("want %t, got %t", want, got)
+	}
+	if fi.ModTime().IsZero() {
+		t.Error("want modification time to be set")
+	}
+	files, err := drv.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := true, len(files) == 1 && fs.IsSpecial(files[0]); got != want {
+		t.Errorf("want %t, got %t", want, got)
+	}
+}
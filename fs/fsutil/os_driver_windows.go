@@ -5,9 +5,34 @@ package fsutil
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
-// WriteFile writes data to filename with permission perm.
+// Lchown only checks whether name exists, since Windows doesn't
+// support Unix ownership.
+func (OSDriver) Lchown(name string, uid, gid int) error {
+	_, err := os.Lstat(name)
+	return err
+}
+
+// WriteFile writes data to filename with permission perm. Data is written to a temporary
+// file first, which then replaces filename, so filename is never left half-written.
 func (OSDriver) WriteFile(filename string, data []byte, perm os.FileMode) error {
-	return ioutil.WriteFile(filename, data, perm)
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	tmpname := f.Name()
+	defer os.Remove(tmpname) // NOP when renaming succeeds
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpname, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpname, filename)
 }
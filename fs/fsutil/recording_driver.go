@@ -0,0 +1,171 @@
+package fsutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+)
+
+// RecordingDriver wraps a driver and writes records for every operation that
+// modifies files to Writer, in JSON lines. Operations that only read files are
+// passed through without being recorded.
+//
+// A record of the intent is written before delegating an operation, and another one
+// with its outcome after it returns, whether it succeeds or not. If the intent can't
+// be written, the operation is not performed and the writing error is returned. If the
+// outcome can't be written, the change has already been applied, so the returned error
+// says so, unless the operation failed, in which case its own error is returned.
+//
+// Writer is not closed, so it may be kept open for as long as the driver is used.
+type RecordingDriver struct {
+	Driver fs.Driver
+	Writer io.Writer
+	// Now returns the time of records. It defaults to time.Now.
+	Now func() time.Time
+
+	mu sync.Mutex
+}
+
+// Record is a modifying operation, as written by RecordingDriver.
+// Arguments not used by an operation are omitted. Done is false for
+// the record of the intent and true for the one of the outcome.
+type Record struct {
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"`
+	Name    string    `json:"name,omitempty"`
+	Oldname string    `json:"oldname,omitempty"`
+	Newname string    `json:"newname,omitempty"`
+	Perm    string    `json:"perm,omitempty"`
+	UID     *int      `json:"uid,omitempty"`
+	GID     *int      `json:"gid,omitempty"`
+	Size    *int      `json:"size,omitempty"`
+	Done    bool      `json:"done"`
+	OK      bool      `json:"ok,omitempty"`
+	Err     string    `json:"error,omitempty"`
+}
+
+// Chmod changes the permission of a file.
+func (drv *RecordingDriver) Chmod(name string, perm os.FileMode) error {
+	return drv.do(Record{Op: "chmod", Name: name, Perm: formatPerm(perm)}, func() error {
+		return drv.Driver.Chmod(name, perm)
+	})
+}
+
+// Lchown changes the owner of a file without following symlinks.
+func (drv *RecordingDriver) Lchown(name string, uid, gid int) error {
+	return drv.do(Record{Op: "lchown", Name: name, UID: &uid, GID: &gid}, func() error {
+		return drv.Driver.Lchown(name, uid, gid)
+	})
+}
+
+// MkdirAll creates directories recursively or is a NOP when they already exist.
+func (drv *RecordingDriver) MkdirAll(dirname string) error {
+	return drv.do(Record{Op: "mkdirall", Name: dirname}, func() error {
+		return drv.Driver.MkdirAll(dirname)
+	})
+}
+
+// ReadDir lists names of files from dirname.
+func (drv *RecordingDriver) ReadDir(dirname string) ([]fs.FileInfo, error) {
+	return drv.Driver.ReadDir(dirname)
+}
+
+// ReadFile returns the content of filename.
+func (drv *RecordingDriver) ReadFile(filename string) ([]byte, error) {
+	return drv.Driver.ReadFile(filename)
+}
+
+// Readlink returns the name of the file a symlink points to.
+func (drv *RecordingDriver) Readlink(name string) (string, error) {
+	return drv.Driver.Readlink(name)
+}
+
+// Remove removes a file or an empty directory.
+func (drv *RecordingDriver) Remove(name string) error {
+	return drv.do(Record{Op: "remove", Name: name}, func() error {
+		return drv.Driver.Remove(name)
+	})
+}
+
+// RemoveAll removes a file and its children, if any.
+func (drv *RecordingDriver) RemoveAll(name string) error {
+	return drv.do(Record{Op: "removeall", Name: name}, func() error {
+		return drv.Driver.RemoveAll(name)
+	})
+}
+
+// Rename moves oldname to newname, replacing newname if it's not a directory.
+func (drv *RecordingDriver) Rename(oldname, newname string) error {
+	return drv.do(Record{Op: "rename", Oldname: oldname, Newname: newname}, func() error {
+		return drv.Driver.Rename(oldname, newname)
+	})
+}
+
+// Stat returns information about a file without following symlinks.
+func (drv *RecordingDriver) Stat(filename string) (fs.FileInfo, error) {
+	return drv.Driver.Stat(filename)
+}
+
+// StatFollow returns information about a file, following symlinks.
+func (drv *RecordingDriver) StatFollow(filename string) (fs.FileInfo, error) {
+	return fs.New(drv.Driver).StatFollow(filename)
+}
+
+// Symlink creates a symbolic link newname of oldname.
+func (drv *RecordingDriver) Symlink(oldname, newname string) error {
+	return drv.do(Record{Op: "symlink", Oldname: oldname, Newname: newname}, func() error {
+		return drv.Driver.Symlink(oldname, newname)
+	})
+}
+
+// WriteFile writes data to filename with permission perm.
+// Only the size of data is recorded.
+func (drv *RecordingDriver) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	size := len(data)
+	return drv.do(Record{Op: "writefile", Name: filename, Perm: formatPerm(perm), Size: &size}, func() error {
+		return drv.Driver.WriteFile(filename, data, perm)
+	})
+}
+
+// do records the intent of r, performs op and then records its outcome.
+func (drv *RecordingDriver) do(r Record, op func() error) error {
+	if err := drv.write(r); err != nil {
+		return fmt.Errorf("fsutil: audit: %w", err)
+	}
+	err := op()
+	r.Done = true
+	r.OK = err == nil
+	if err != nil {
+		r.Err = err.Error()
+	}
+	if werr := drv.write(r); werr != nil && err == nil {
+		return fmt.Errorf("fsutil: audit: %s applied but not recorded: %w", r.Op, werr)
+	}
+	return err
+}
+
+func (drv *RecordingDriver) write(r Record) error {
+	now := time.Now
+	if drv.Now != nil {
+		now = drv.Now
+	}
+	r.Time = now()
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+	// Write the whole line at once so that concurrent records don't get mixed up.
+	_, err = drv.Writer.Write(append(b, '\n'))
+	return err
+}
+
+func formatPerm(perm os.FileMode) string {
+	return fmt.Sprintf("%#o", perm)
+}
@@ -0,0 +1,142 @@
+package fsutil_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+	"github.com/gbrlsnchs/pilgo/fs/fstest"
+	"github.com/gbrlsnchs/pilgo/fs/fsutil"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecordingDriver(t *testing.T) {
+	t.Run("Conformance", testRecordingDriverConformance)
+	t.Run("Record", testRecordingDriverRecord)
+	t.Run("WriteErr", testRecordingDriverWriteErr)
+}
+
+func testRecordingDriverConformance(t *testing.T) {
+	fstest.TestDriver(t, func(*testing.T) fs.Driver {
+		return &fsutil.RecordingDriver{
+			Driver: new(fstest.InMemoryDriver),
+			Writer: ioutil.Discard,
+		}
+	})
+}
+
+func testRecordingDriverRecord(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		drv = &fsutil.RecordingDriver{
+			Driver: new(fstest.InMemoryDriver),
+			Writer: &buf,
+			Now: func() time.Time {
+				return time.Date(2021, time.January, 2, 3, 4, 5, 0, time.UTC)
+			},
+		}
+	)
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(drv.MkdirAll("foo"))
+	must(drv.WriteFile("bar", []byte("bar"), 0o644))
+	// Operations that only read files are not recorded.
+	if _, err := drv.ReadDir("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := drv.ReadFile("bar"); err != nil {
+		t.Fatal(err)
+	}
+	must(drv.Chmod("bar", 0o600))
+	must(drv.Lchown("bar", -1, -1))
+	must(drv.Symlink("bar", "baz"))
+	must(drv.Rename("baz", "qux"))
+	if err := drv.Remove("nonexistent"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("want %v, got %v", os.ErrNotExist, err)
+	}
+	must(drv.Remove("qux"))
+	must(drv.RemoveAll("foo"))
+	// Every operation is recorded before and after it is performed.
+	want := []string{
+		`{"time":"2021-01-02T03:04:05Z","op":"mkdirall","name":"foo","done":false}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"mkdirall","name":"foo","done":true,"ok":true}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"writefile","name":"bar","perm":"0644","size":3,"done":false}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"writefile","name":"bar","perm":"0644","size":3,"done":true,"ok":true}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"chmod","name":"bar","perm":"0600","done":false}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"chmod","name":"bar","perm":"0600","done":true,"ok":true}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"lchown","name":"bar","uid":-1,"gid":-1,"done":false}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"lchown","name":"bar","uid":-1,"gid":-1,"done":true,"ok":true}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"symlink","oldname":"bar","newname":"baz","done":false}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"symlink","oldname":"bar","newname":"baz","done":true,"ok":true}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"rename","oldname":"baz","newname":"qux","done":false}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"rename","oldname":"baz","newname":"qux","done":true,"ok":true}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"remove","name":"nonexistent","done":false}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"remove","name":"nonexistent","done":true,"error":"fstest: nonexistent: file doesn't exist"}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"remove","name":"qux","done":false}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"remove","name":"qux","done":true,"ok":true}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"removeall","name":"foo","done":false}`,
+		`{"time":"2021-01-02T03:04:05Z","op":"removeall","name":"foo","done":true,"ok":true}`,
+	}
+	got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if !cmp.Equal(got, want) {
+		t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+// errWriter fails every write after the first n ones.
+type errWriter struct {
+	n   int
+	err error
+}
+
+func (w *errWriter) Write(b []byte) (int, error) {
+	if w.n == 0 {
+		return 0, w.err
+	}
+	w.n--
+	return len(b), nil
+}
+
+func testRecordingDriverWriteErr(t *testing.T) {
+	errWrite := errors.New("write error")
+	newDriver := func(n int) (*fsutil.RecordingDriver, *fstest.InMemoryDriver) {
+		drv := new(fstest.InMemoryDriver)
+		return &fsutil.RecordingDriver{
+			Driver: drv,
+			Writer: &errWriter{n, errWrite},
+		}, drv
+	}
+	// Operations whose intent can't be recorded are not performed.
+	rec, drv := newDriver(0)
+	if want, got := errWrite, rec.MkdirAll("foo"); !errors.Is(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	if fi, err := drv.Stat("foo"); err != nil || fi.Exists() {
+		t.Fatalf("want %q not to be created, got %v", "foo", err)
+	}
+	// Operations whose outcome can't be recorded have already been performed.
+	rec, drv = newDriver(1)
+	err := rec.MkdirAll("foo")
+	if want, got := errWrite, err; !errors.Is(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	if want, got := "fsutil: audit: mkdirall applied but not recorded: write error", err.Error(); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if fi, err := drv.Stat("foo"); err != nil || !fi.Exists() {
+		t.Fatalf("want %q to be created, got %v", "foo", err)
+	}
+	// The operation's own error takes precedence.
+	rec, _ = newDriver(1)
+	if want, got := os.ErrNotExist, rec.Remove("bar"); !errors.Is(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,319 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+)
+
+// maxLinks is how many symlinks are followed before giving up.
+const maxLinks = 40
+
+// ErrEscape means a path or a symlink resolves to somewhere outside the root directory.
+var ErrEscape = errors.New("path escapes root directory")
+
+// RootedDriver is a driver for a concrete file system that confines every operation
+// to Root, as if it were the file system's root directory. Absolute paths are mapped
+// under Root and relative ones are resolved against the working directory, which
+// must be inside Root.
+//
+// Symlinks are resolved the same way, so absolute linknames also point under Root.
+// They are written exactly as given, though, which means symlinks still point to the
+// expected files once Root becomes the real root directory, e.g. in a container image.
+//
+// Any path or symlink that escapes Root, either by using ".." or by pointing outside
+// of it, results in ErrEscape.
+type RootedDriver struct {
+	Root string
+	// PreserveCRLF is the same as in OSDriver.
+	PreserveCRLF bool
+}
+
+// Chmod changes the permission of a file.
+func (drv RootedDriver) Chmod(name string, perm os.FileMode) error {
+	filename, err := drv.resolve(name, true)
+	if err != nil {
+		return err
+	}
+	return pathError(OSDriver{}.Chmod(filename, perm), name)
+}
+
+// Getwd returns the working directory as an absolute path relative to Root.
+func (drv RootedDriver) Getwd() (string, error) {
+	root, err := drv.root()
+	if err != nil {
+		return "", err
+	}
+	return getwd(root)
+}
+
+// Lchown changes the owner of a file without following symlinks.
+func (drv RootedDriver) Lchown(name string, uid, gid int) error {
+	filename, err := drv.resolve(name, false)
+	if err != nil {
+		return err
+	}
+	return pathError(OSDriver{}.Lchown(filename, uid, gid), name)
+}
+
+// MkdirAll creates directories recursively or is a NOP when they already exist.
+func (drv RootedDriver) MkdirAll(dirname string) error {
+	filename, err := drv.resolve(dirname, true)
+	if err != nil {
+		return err
+	}
+	return pathError(OSDriver{}.MkdirAll(filename), dirname)
+}
+
+// ReadDir lists names of files from dirname.
+func (drv RootedDriver) ReadDir(dirname string) ([]fs.FileInfo, error) {
+	filename, err := drv.resolve(dirname, true)
+	if err != nil {
+		return nil, err
+	}
+	files, err := OSDriver{}.ReadDir(filename)
+	return files, pathError(err, dirname)
+}
+
+// ReadFile returns the content of filename.
+// Unless CRLF newlines are preserved, it transforms them into LF only.
+func (drv RootedDriver) ReadFile(filename string) ([]byte, error) {
+	name, err := drv.resolve(filename, true)
+	if err != nil {
+		return nil, err
+	}
+	b, err := OSDriver{PreserveCRLF: drv.PreserveCRLF}.ReadFile(name)
+	return b, pathError(err, filename)
+}
+
+// Readlink returns the name of the file a symlink points to, exactly as it was written.
+func (drv RootedDriver) Readlink(name string) (string, error) {
+	filename, err := drv.resolve(name, false)
+	if err != nil {
+		return "", err
+	}
+	linkname, err := OSDriver{}.Readlink(filename)
+	return linkname, pathError(err, name)
+}
+
+// Remove removes a file or an empty directory.
+func (drv RootedDriver) Remove(name string) error {
+	filename, err := drv.resolve(name, false)
+	if err != nil {
+		return err
+	}
+	return pathError(OSDriver{}.Remove(filename), name)
+}
+
+// RemoveAll removes a file and its children, if any.
+func (drv RootedDriver) RemoveAll(name string) error {
+	filename, err := drv.resolve(name, false)
+	if err != nil {
+		return err
+	}
+	return pathError(OSDriver{}.RemoveAll(filename), name)
+}
+
+// Rename moves oldname to newname, replacing newname if it's not a directory.
+func (drv RootedDriver) Rename(oldname, newname string) error {
+	oldpath, err := drv.resolve(oldname, false)
+	if err != nil {
+		return err
+	}
+	newpath, err := drv.resolve(newname, false)
+	if err != nil {
+		return err
+	}
+	return pathError(OSDriver{}.Rename(oldpath, newpath), oldname, newname)
+}
+
+// Stat returns real information about a file. It doesn't follow symlinks.
+func (drv RootedDriver) Stat(filename string) (fs.FileInfo, error) {
+	name, err := drv.resolve(filename, false)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := OSDriver{}.Stat(name)
+	return fi, pathError(err, filename)
+}
+
+// StatFollow returns real information about a file, following symlinks.
+func (drv RootedDriver) StatFollow(filename string) (fs.FileInfo, error) {
+	name, err := drv.resolve(filename, true)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := OSDriver{}.Stat(name)
+	if err != nil {
+		return nil, pathError(err, filename)
+	}
+	// Report the name of the symlink itself, like os.Stat does.
+	if info, ok := fi.(fileInfo); ok && info.exists {
+		info.name = filepath.Base(filename)
+		return info, nil
+	}
+	return fi, nil
+}
+
+// Symlink creates a symbolic link newname of oldname. It fails if
+// oldname points outside of Root, even when it doesn't exist yet.
+func (drv RootedDriver) Symlink(oldname, newname string) error {
+	root, elems, err := drv.split(newname)
+	if err != nil {
+		return err
+	}
+	dir, err := walk(root, elems, false)
+	if err != nil {
+		return fmt.Errorf("fsutil: %s: %w", newname, err)
+	}
+	target, abs := splitPath(oldname)
+	if !abs && len(dir) > 0 {
+		target = append(append([]string(nil), dir[:len(dir)-1]...), target...)
+	}
+	if _, err := walk(root, target, true); err != nil {
+		return fmt.Errorf("fsutil: %s: %w", oldname, err)
+	}
+	return pathError(OSDriver{}.Symlink(oldname, join(root, dir)), oldname, newname)
+}
+
+// WriteFile writes data to filename atomically with permission perm.
+func (drv RootedDriver) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	name, err := drv.resolve(filename, false)
+	if err != nil {
+		return err
+	}
+	return pathError(OSDriver{}.WriteFile(name, data, perm), filename)
+}
+
+func (drv RootedDriver) root() (string, error) {
+	root, err := filepath.Abs(drv.Root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(root)
+}
+
+// resolve maps name to a real path under the root directory, resolving
+// every symlink along the way. The last element is only resolved when follow is true.
+func (drv RootedDriver) resolve(name string, follow bool) (string, error) {
+	root, elems, err := drv.split(name)
+	if err != nil {
+		return "", err
+	}
+	dir, err := walk(root, elems, follow)
+	if err != nil {
+		return "", fmt.Errorf("fsutil: %s: %w", name, err)
+	}
+	return join(root, dir), nil
+}
+
+// split returns the elements of name as an absolute path relative to the root directory.
+func (drv RootedDriver) split(name string) (string, []string, error) {
+	root, err := drv.root()
+	if err != nil {
+		return "", nil, err
+	}
+	elems, abs := splitPath(name)
+	if !abs {
+		wd, err := getwd(root)
+		if err != nil {
+			return "", nil, err
+		}
+		wdElems, _ := splitPath(wd)
+		elems = append(wdElems, elems...)
+	}
+	return root, elems, nil
+}
+
+// walk resolves elems under root, following symlinks. Since ".." is handled before
+// reaching the real file system, walking above root is detected instead of ignored.
+func walk(root string, elems []string, follow bool) ([]string, error) {
+	var (
+		dir   []string
+		links int
+	)
+	for len(elems) > 0 {
+		elem := elems[0]
+		elems = elems[1:]
+		switch elem {
+		case ".":
+			continue
+		case "..":
+			if len(dir) == 0 {
+				return nil, ErrEscape
+			}
+			dir = dir[:len(dir)-1]
+			continue
+		}
+		dir = append(dir, elem)
+		if len(elems) == 0 && !follow {
+			break
+		}
+		filename := join(root, dir)
+		// Errors are left for the actual operation to report.
+		fi, err := os.Lstat(filename)
+		if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		if links++; links > maxLinks {
+			return nil, fs.ErrTooManyLinks
+		}
+		linkname, err := os.Readlink(filename)
+		if err != nil {
+			return nil, err
+		}
+		target, abs := splitPath(linkname)
+		if dir = dir[:len(dir)-1]; abs {
+			dir = nil
+		}
+		elems = append(target, elems...)
+	}
+	return dir, nil
+}
+
+func getwd(root string) (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if wd, err = filepath.EvalSymlinks(wd); err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, wd)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("fsutil: %s: %w", wd, ErrEscape)
+	}
+	return filepath.Join(string(filepath.Separator), rel), nil
+}
+
+// splitPath returns the elements of name without its volume name and
+// reports whether name is absolute.
+func splitPath(name string) ([]string, bool) {
+	name = name[len(filepath.VolumeName(name)):]
+	elems := strings.FieldsFunc(name, func(r rune) bool {
+		return r < 0x80 && os.IsPathSeparator(uint8(r))
+	})
+	return elems, len(name) > 0 && os.IsPathSeparator(name[0])
+}
+
+func join(root string, elems []string) string {
+	return filepath.Join(append([]string{root}, elems...)...)
+}
+
+// pathError replaces real paths in err with the ones known by callers.
+func pathError(err error, names ...string) error {
+	switch err := err.(type) {
+	case *os.PathError:
+		return &os.PathError{Op: err.Op, Path: names[0], Err: err.Err}
+	case *os.LinkError:
+		return &os.LinkError{Op: err.Op, Old: names[0], New: names[len(names)-1], Err: err.Err}
+	}
+	return err
+}
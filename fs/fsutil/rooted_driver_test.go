@@ -0,0 +1,256 @@
+package fsutil_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+	"github.com/gbrlsnchs/pilgo/fs/fstest"
+	"github.com/gbrlsnchs/pilgo/fs/fsutil"
+)
+
+func TestRootedDriver(t *testing.T) {
+	t.Run("Conformance", testRootedDriverConformance)
+	t.Run("Escape", testRootedDriverEscape)
+	t.Run("Getwd", testRootedDriverGetwd)
+	t.Run("Symlink", testRootedDriverSymlink)
+}
+
+// setupRoot creates the following structure inside a temporary directory,
+// which also contains an "outside" file as a sibling of "root":
+//
+//	root
+//	├── abs -> /home
+//	├── escape -> ../outside
+//	├── escapeabs -> /../outside
+//	├── home
+//	│   ├── config -> ../etc
+//	│   └── file
+//	├── etc
+//	│   └── file
+//	└── loop -> loop
+func setupRoot(t *testing.T) (string, func()) {
+	tmp, err := ioutil.TempDir("", "pilgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp, err = filepath.EvalSymlinks(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(tmp, "root")
+	for _, dir := range []string{"home", "etc"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(tmp, "outside"):       "outside",
+		filepath.Join(root, "home", "file"): "home",
+		filepath.Join(root, "etc", "file"):  "etc",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(root, "abs"):            string(filepath.Separator) + "home",
+		filepath.Join(root, "escape"):         filepath.Join("..", "outside"),
+		filepath.Join(root, "escapeabs"):      rawPath("", "..", "outside"),
+		filepath.Join(root, "home", "config"): filepath.Join("..", "etc"),
+		filepath.Join(root, "loop"):           "loop",
+	}
+	for name, linkname := range links {
+		if err := os.Symlink(linkname, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root, func() { os.RemoveAll(tmp) }
+}
+
+// rawPath joins elems without cleaning the resulting path.
+func rawPath(elems ...string) string {
+	return strings.Join(elems, string(filepath.Separator))
+}
+
+func testRootedDriverConformance(t *testing.T) {
+	fstest.TestDriver(t, func(t *testing.T) fs.Driver {
+		return fsutil.RootedDriver{Root: chdirTemp(t)}
+	})
+}
+
+func testRootedDriverEscape(t *testing.T) {
+	root, cleanup := setupRoot(t)
+	defer cleanup()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(filepath.Join(root, "home")); err != nil {
+		t.Fatal(err)
+	}
+	sep := string(filepath.Separator)
+	testCases := []struct {
+		name     string
+		filename string
+		want     string
+		err      error
+	}{
+		{"absolute", filepath.Join(sep, "home", "file"), "home", nil},
+		{"relative", "file", "home", nil},
+		{"dot dot inside root", filepath.Join("..", "etc", "file"), "etc", nil},
+		{"dot dot above root", filepath.Join("..", "..", "outside"), "", fsutil.ErrEscape},
+		{"absolute dot dot above root", rawPath("", "..", "outside"), "", fsutil.ErrEscape},
+		{"dot dot above root and back", rawPath("..", "..", "root", "etc", "file"), "", fsutil.ErrEscape},
+		{"relative symlink", filepath.Join("config", "file"), "etc", nil},
+		{"absolute symlink", filepath.Join(sep, "abs", "file"), "home", nil},
+		{"dot dot through symlink", rawPath("config", "..", "home", "file"), "home", nil},
+		{"symlink above root", filepath.Join(sep, "escape"), "", fsutil.ErrEscape},
+		{"absolute symlink above root", filepath.Join(sep, "escapeabs"), "", fsutil.ErrEscape},
+		{"dot dot through symlink above root", rawPath("config", "..", "..", "..", "outside"), "", fsutil.ErrEscape},
+		{"symlink loop", filepath.Join(sep, "loop"), "", fs.ErrTooManyLinks},
+		{"nonexistent", "nonexistent", "", os.ErrNotExist},
+	}
+	drv := fsutil.RootedDriver{Root: root}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := drv.ReadFile(tc.filename)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if want, got := tc.want, string(b); got != want {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+		})
+	}
+	t.Run("Stat", func(t *testing.T) {
+		// Symlinks that escape can still be inspected, as long as they're not followed.
+		fi, err := drv.Stat(filepath.Join(sep, "escape"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := filepath.Join("..", "outside"), fi.Linkname(); got != want {
+			t.Fatalf("want %q, got %q", want, got)
+		}
+		if _, err := drv.StatFollow(filepath.Join(sep, "escape")); !errors.Is(err, fsutil.ErrEscape) {
+			t.Fatalf("want %v, got %v", fsutil.ErrEscape, err)
+		}
+	})
+	t.Run("Modify", func(t *testing.T) {
+		outside := filepath.Join("..", "..", "outside")
+		errs := []error{
+			drv.Chmod(outside, 0o600),
+			drv.Chmod(filepath.Join(sep, "escape"), 0o600),
+			drv.MkdirAll(filepath.Join(sep, "escape", "foo")),
+			drv.Remove(outside),
+			drv.RemoveAll(outside),
+			drv.Rename("file", outside),
+			drv.Rename(outside, "file"),
+			drv.WriteFile(outside, nil, 0o644),
+			drv.WriteFile(filepath.Join(sep, "escape", "foo"), nil, 0o644),
+		}
+		for i, err := range errs {
+			if want, got := fsutil.ErrEscape, err; !errors.Is(got, want) {
+				t.Errorf("#%d: want %v, got %v", i, want, got)
+			}
+		}
+		b, err := ioutil.ReadFile(filepath.Join(root, "..", "outside"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := "outside", string(b); got != want {
+			t.Fatalf("want %q, got %q", want, got)
+		}
+	})
+}
+
+func testRootedDriverGetwd(t *testing.T) {
+	root, cleanup := setupRoot(t)
+	defer cleanup()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	drv := fsutil.RootedDriver{Root: root}
+	testCases := []struct {
+		dir  string
+		want string
+		err  error
+	}{
+		{root, string(filepath.Separator), nil},
+		{filepath.Join(root, "home"), filepath.Join(string(filepath.Separator), "home"), nil},
+		{filepath.Join(root, "home", "config"), filepath.Join(string(filepath.Separator), "etc"), nil},
+		{filepath.Dir(root), "", fsutil.ErrEscape},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.dir, func(t *testing.T) {
+			if err := os.Chdir(tc.dir); err != nil {
+				t.Fatal(err)
+			}
+			cwd, err := drv.Getwd()
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if want, got := tc.want, cwd; got != want {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+		})
+	}
+	if _, err := drv.ReadFile("outside"); !errors.Is(err, fsutil.ErrEscape) {
+		t.Fatalf("want %v, got %v", fsutil.ErrEscape, err)
+	}
+}
+
+func testRootedDriverSymlink(t *testing.T) {
+	root, cleanup := setupRoot(t)
+	defer cleanup()
+	sep := string(filepath.Separator)
+	testCases := []struct {
+		name             string
+		oldname, newname string
+		err              error
+	}{
+		{"absolute", filepath.Join(sep, "etc", "file"), filepath.Join(sep, "home", "foo"), nil},
+		{"relative", filepath.Join("..", "etc", "file"), filepath.Join(sep, "home", "bar"), nil},
+		{"dangling", filepath.Join(sep, "nonexistent"), filepath.Join(sep, "home", "baz"), nil},
+		{"through symlink", filepath.Join("..", "home", "file"), filepath.Join(sep, "home", "config", "qux"), nil},
+		{"dot dot above root", filepath.Join("..", "..", "outside"), filepath.Join(sep, "home", "escape"), fsutil.ErrEscape},
+		{"absolute dot dot above root", rawPath("", "..", "outside"), filepath.Join(sep, "home", "escape"), fsutil.ErrEscape},
+		{"through escaping symlink", filepath.Join(sep, "escape"), filepath.Join(sep, "home", "escape"), fsutil.ErrEscape},
+		{"newname above root", filepath.Join(sep, "etc", "file"), rawPath("", "..", "escape"), fsutil.ErrEscape},
+	}
+	drv := fsutil.RootedDriver{Root: root}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := drv.Symlink(tc.oldname, tc.newname)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if err != nil {
+				return
+			}
+			// Linknames are written as given, without the root directory.
+			linkname, err := drv.Readlink(tc.newname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, got := tc.oldname, linkname; got != want {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+		})
+	}
+	b, err := drv.ReadFile(filepath.Join(sep, "home", "foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "etc", string(b); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,322 @@
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// ErrReadOnly means a file system can't be modified.
+	ErrReadOnly = errors.New("read-only file system")
+	// ErrUnsupported means an operation is not supported by a file system.
+	ErrUnsupported = errors.New("operation not supported")
+)
+
+// linkReader is implemented by io/fs file systems that support symlinks.
+type linkReader interface {
+	ReadLink(name string) (string, error)
+	Lstat(name string) (iofs.FileInfo, error)
+}
+
+// FromIOFS creates a read-only driver from an io/fs file system. Every method that
+// modifies files returns ErrReadOnly.
+//
+// If fsys implements both ReadLink and Lstat methods, symlinks are not followed
+// when calling Stat and have their linknames read. Otherwise, symlinks are handled
+// as whatever fsys returns for them.
+func FromIOFS(fsys iofs.FS) Driver {
+	return ioDriver{fsys}
+}
+
+type ioDriver struct {
+	fsys iofs.FS
+}
+
+func (drv ioDriver) Chmod(name string, _ os.FileMode) error {
+	return fmt.Errorf("fs: %s: %w", name, ErrReadOnly)
+}
+
+func (drv ioDriver) Lchown(name string, _, _ int) error {
+	return fmt.Errorf("fs: %s: %w", name, ErrReadOnly)
+}
+
+func (drv ioDriver) MkdirAll(dirname string) error {
+	return fmt.Errorf("fs: %s: %w", dirname, ErrReadOnly)
+}
+
+func (drv ioDriver) ReadDir(dirname string) ([]FileInfo, error) {
+	dirname = ioPath(dirname)
+	entries, err := iofs.ReadDir(drv.fsys, dirname)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]FileInfo, len(entries))
+	for i, e := range entries {
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		if files[i], err = drv.newFileInfo(path.Join(dirname, e.Name()), fi); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func (drv ioDriver) ReadFile(filename string) ([]byte, error) {
+	return iofs.ReadFile(drv.fsys, ioPath(filename))
+}
+
+func (drv ioDriver) Readlink(name string) (string, error) {
+	lr, ok := drv.fsys.(linkReader)
+	if !ok {
+		return "", fmt.Errorf("fs: %s: %w", name, ErrUnsupported)
+	}
+	linkname, err := lr.ReadLink(ioPath(name))
+	return filepath.FromSlash(linkname), err
+}
+
+func (drv ioDriver) Remove(name string) error {
+	return fmt.Errorf("fs: %s: %w", name, ErrReadOnly)
+}
+
+func (drv ioDriver) RemoveAll(name string) error {
+	return fmt.Errorf("fs: %s: %w", name, ErrReadOnly)
+}
+
+func (drv ioDriver) Rename(oldname, _ string) error {
+	return fmt.Errorf("fs: %s: %w", oldname, ErrReadOnly)
+}
+
+func (drv ioDriver) Stat(filename string) (FileInfo, error) {
+	statFn := func(name string) (iofs.FileInfo, error) { return iofs.Stat(drv.fsys, name) }
+	if lr, ok := drv.fsys.(linkReader); ok {
+		statFn = lr.Lstat
+	}
+	return drv.stat(filename, statFn)
+}
+
+func (drv ioDriver) StatFollow(filename string) (FileInfo, error) {
+	return drv.stat(filename, func(name string) (iofs.FileInfo, error) {
+		return iofs.Stat(drv.fsys, name)
+	})
+}
+
+func (drv ioDriver) Symlink(_, newname string) error {
+	return fmt.Errorf("fs: %s: %w", newname, ErrReadOnly)
+}
+
+func (drv ioDriver) WriteFile(filename string, _ []byte, _ os.FileMode) error {
+	return fmt.Errorf("fs: %s: %w", filename, ErrReadOnly)
+}
+
+func (drv ioDriver) stat(filename string, statFn func(string) (iofs.FileInfo, error)) (FileInfo, error) {
+	filename = ioPath(filename)
+	fi, err := statFn(filename)
+	if err != nil {
+		if !errors.Is(err, iofs.ErrNotExist) {
+			return nil, err
+		}
+		return nonexistentFile{}, nil
+	}
+	return drv.newFileInfo(filename, fi)
+}
+
+func (drv ioDriver) newFileInfo(name string, fi iofs.FileInfo) (FileInfo, error) {
+	info := ioFileInfo{fi, ""}
+	if lr, ok := drv.fsys.(linkReader); ok && info.IsSymlink() {
+		linkname, err := lr.ReadLink(name)
+		if err != nil {
+			return nil, err
+		}
+		info.linkname = filepath.FromSlash(linkname)
+	}
+	return info, nil
+}
+
+// ioPath converts an OS path into a path valid for io/fs.
+func ioPath(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+type ioFileInfo struct {
+	iofs.FileInfo
+	linkname string
+}
+
+func (fi ioFileInfo) Exists() bool      { return true }
+func (fi ioFileInfo) IsSymlink() bool   { return fi.Mode()&os.ModeSymlink != 0 }
+func (fi ioFileInfo) Linkname() string  { return fi.linkname }
+func (fi ioFileInfo) Perm() os.FileMode { return fi.Mode().Perm() }
+
+type nonexistentFile struct{}
+
+func (nonexistentFile) Name() string       { return "" }
+func (nonexistentFile) Exists() bool       { return false }
+func (nonexistentFile) IsDir() bool        { return false }
+func (nonexistentFile) IsSymlink() bool    { return false }
+func (nonexistentFile) Linkname() string   { return "" }
+func (nonexistentFile) Perm() os.FileMode  { return 0 }
+func (nonexistentFile) Mode() os.FileMode  { return 0 }
+func (nonexistentFile) ModTime() time.Time { return time.Time{} }
+func (nonexistentFile) Size() int64        { return 0 }
+
+// AsIOFS exposes fs as an io/fs file system. Symlinks are followed, except
+// when using its ReadLink and Lstat methods.
+func (fs FileSystem) AsIOFS() iofs.FS {
+	fs.testDriver()
+	return ioFS{fs}
+}
+
+type ioFS struct {
+	fs FileSystem
+}
+
+func (fsys ioFS) Open(name string) (iofs.File, error) {
+	fi, err := fsys.stat("open", name, fsys.fs.StatFollow)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		entries, err := fsys.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &ioDir{name, fi, entries}, nil
+	}
+	b, err := fsys.fs.ReadFile(name)
+	if err != nil {
+		return nil, &iofs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &ioFile{name, fi, bytes.NewReader(b)}, nil
+}
+
+func (fsys ioFS) ReadDir(name string) ([]iofs.DirEntry, error) {
+	if !iofs.ValidPath(name) {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: iofs.ErrInvalid}
+	}
+	files, err := fsys.fs.ReadDir(name)
+	if err != nil {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	entries := make([]iofs.DirEntry, len(files))
+	for i, fi := range files {
+		entries[i] = dirEntry{fileInfo{fi, fi.Name()}}
+	}
+	return entries, nil
+}
+
+func (fsys ioFS) ReadFile(name string) ([]byte, error) {
+	fi, err := fsys.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, &iofs.PathError{Op: "read", Path: name, Err: iofs.ErrInvalid}
+	}
+	b, err := fsys.fs.ReadFile(name)
+	if err != nil {
+		return nil, &iofs.PathError{Op: "read", Path: name, Err: err}
+	}
+	// Callers are allowed to modify the returned data.
+	return append([]byte(nil), b...), nil
+}
+
+// ReadLink returns the destination of a symlink.
+func (fsys ioFS) ReadLink(name string) (string, error) {
+	if !iofs.ValidPath(name) {
+		return "", &iofs.PathError{Op: "readlink", Path: name, Err: iofs.ErrInvalid}
+	}
+	linkname, err := fsys.fs.Readlink(name)
+	if err != nil {
+		return "", &iofs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return filepath.ToSlash(linkname), nil
+}
+
+// Lstat returns information about a file without following symlinks.
+func (fsys ioFS) Lstat(name string) (iofs.FileInfo, error) {
+	return fsys.stat("lstat", name, fsys.fs.Stat)
+}
+
+func (fsys ioFS) Stat(name string) (iofs.FileInfo, error) {
+	return fsys.stat("stat", name, fsys.fs.StatFollow)
+}
+
+func (fsys ioFS) stat(op, name string, statFn func(string) (FileInfo, error)) (iofs.FileInfo, error) {
+	if !iofs.ValidPath(name) {
+		return nil, &iofs.PathError{Op: op, Path: name, Err: iofs.ErrInvalid}
+	}
+	fi, err := statFn(name)
+	if err != nil {
+		return nil, &iofs.PathError{Op: op, Path: name, Err: err}
+	}
+	if !fi.Exists() {
+		return nil, &iofs.PathError{Op: op, Path: name, Err: iofs.ErrNotExist}
+	}
+	return fileInfo{fi, path.Base(name)}, nil
+}
+
+// fileInfo adapts FileInfo to io/fs.
+type fileInfo struct {
+	FileInfo
+	name string
+}
+
+func (fi fileInfo) Name() string     { return fi.name }
+func (fi fileInfo) Sys() interface{} { return nil }
+
+type dirEntry struct {
+	fi fileInfo
+}
+
+func (e dirEntry) Name() string                 { return e.fi.Name() }
+func (e dirEntry) IsDir() bool                  { return e.fi.IsDir() }
+func (e dirEntry) Type() iofs.FileMode          { return e.fi.Mode().Type() }
+func (e dirEntry) Info() (iofs.FileInfo, error) { return e.fi, nil }
+
+type ioFile struct {
+	name string
+	info iofs.FileInfo
+	*bytes.Reader
+}
+
+func (f *ioFile) Stat() (iofs.FileInfo, error) { return f.info, nil }
+func (f *ioFile) Close() error                 { return nil }
+
+type ioDir struct {
+	name    string
+	info    iofs.FileInfo
+	entries []iofs.DirEntry
+}
+
+func (d *ioDir) Stat() (iofs.FileInfo, error) { return d.info, nil }
+func (d *ioDir) Close() error                 { return nil }
+
+func (d *ioDir) Read([]byte) (int, error) {
+	return 0, &iofs.PathError{Op: "read", Path: d.name, Err: iofs.ErrInvalid}
+}
+
+func (d *ioDir) ReadDir(n int) ([]iofs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,148 @@
+package fs_test
+
+import (
+	"errors"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	iofstest "testing/fstest"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+	"github.com/gbrlsnchs/pilgo/fs/fstest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFromIOFS(t *testing.T) {
+	mapfs := iofstest.MapFS{
+		"foo":         {Data: []byte("foo"), Mode: 0o644},
+		"bar/baz":     {Data: []byte("baz"), Mode: 0o600},
+		"bar/qux":     {Mode: os.ModeDir | 0o755},
+		"bar/quux":    {Mode: os.ModeNamedPipe | 0o644},
+		"empty":       {Mode: os.ModeDir | 0o700},
+		"bar/corge/x": {Data: []byte("x")},
+	}
+	drv := fs.FromIOFS(mapfs)
+	t.Run("Stat", func(t *testing.T) {
+		type info struct {
+			Exists, IsDir bool
+			Mode          os.FileMode
+			Size          int64
+		}
+		testCases := []struct {
+			name string
+			want info
+		}{
+			{"foo", info{true, false, 0o644, 3}},
+			{filepath.Join("bar", "baz"), info{true, false, 0o600, 3}},
+			{"empty", info{true, true, os.ModeDir | 0o700, 0}},
+			{filepath.Join("bar", "quux"), info{true, false, os.ModeNamedPipe | 0o644, 0}},
+			{"nonexistent", info{false, false, 0, 0}},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				fi, err := drv.Stat(tc.name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := info{fi.Exists(), fi.IsDir(), fi.Mode(), fi.Size()}
+				if want := tc.want; got != want {
+					t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+				}
+			})
+		}
+	})
+	t.Run("ReadDir", func(t *testing.T) {
+		files, err := drv.ReadDir("bar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, fi := range files {
+			names = append(names, fi.Name())
+		}
+		if want, got := []string{"baz", "corge", "quux", "qux"}, names; !cmp.Equal(got, want) {
+			t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+		}
+		if _, err := drv.ReadDir("nonexistent"); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("want %v, got %v", os.ErrNotExist, err)
+		}
+	})
+	t.Run("ReadFile", func(t *testing.T) {
+		b, err := drv.ReadFile(filepath.Join("bar", "baz"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := "baz", string(b); got != want {
+			t.Fatalf("want %q, got %q", want, got)
+		}
+	})
+	t.Run("Readlink", func(t *testing.T) {
+		// Hide methods other than Open.
+		drv := fs.FromIOFS(struct{ iofs.FS }{mapfs})
+		if _, err := drv.Readlink("foo"); !errors.Is(err, fs.ErrUnsupported) {
+			t.Fatalf("want %v, got %v", fs.ErrUnsupported, err)
+		}
+	})
+	t.Run("ReadOnly", func(t *testing.T) {
+		errs := []error{
+			drv.Chmod("foo", 0o600),
+			drv.Lchown("foo", 0, 0),
+			drv.MkdirAll("qux"),
+			drv.Remove("foo"),
+			drv.RemoveAll("bar"),
+			drv.Rename("foo", "qux"),
+			drv.Symlink("foo", "qux"),
+			drv.WriteFile("foo", nil, 0o644),
+		}
+		for _, err := range errs {
+			if want, got := fs.ErrReadOnly, err; !errors.Is(got, want) {
+				t.Errorf("want %v, got %v", want, got)
+			}
+		}
+	})
+}
+
+func TestFileSystemAsIOFS(t *testing.T) {
+	drv := &fstest.InMemoryDriver{
+		CurrentDir: "root",
+		Files: map[string]fstest.File{
+			"root": {Children: map[string]fstest.File{
+				"foo": {Data: []byte("foo"), Perm: 0o644},
+				"bar": {Children: map[string]fstest.File{
+					"baz": {Data: []byte("baz"), Perm: 0o600},
+					"qux": {Children: map[string]fstest.File{}, Perm: 0o755},
+				}, Perm: 0o755},
+				"link": {Linkname: "bar"},
+			}},
+		},
+	}
+	fsys := fs.New(drv).AsIOFS()
+	if err := iofstest.TestFS(fsys, "foo", "bar/baz", "bar/qux"); err != nil {
+		t.Fatal(err)
+	}
+	lfs, ok := fsys.(interface {
+		ReadLink(name string) (string, error)
+		Lstat(name string) (iofs.FileInfo, error)
+	})
+	if !ok {
+		t.Fatal("want ReadLink and Lstat methods")
+	}
+	linkname, err := lfs.ReadLink("link")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "bar", linkname; got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	fi, err := lfs.Lstat("link")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := os.ModeSymlink, fi.Mode().Type(); got != want {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	if _, err := fsys.Open("nonexistent"); !errors.Is(err, iofs.ErrNotExist) {
+		t.Fatalf("want %v, got %v", iofs.ErrNotExist, err)
+	}
+}
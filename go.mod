@@ -1,8 +1,9 @@
 module github.com/gbrlsnchs/pilgo
 
-go 1.13
+go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/andybalholm/crlf v0.0.0-20171020200849-670099aa064f
 	github.com/gbrlsnchs/cli v0.7.1
 	github.com/google/go-cmdtest v0.2.1-0.20200427163723-7ae72be89103
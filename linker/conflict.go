@@ -0,0 +1,87 @@
+package linker
+
+import (
+	"fmt"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+	"github.com/gbrlsnchs/pilgo/parser"
+)
+
+// ConflictKind is the reason why a node can't be symlinked.
+type ConflictKind int
+
+const (
+	// LinkExist means a symlink to another file exists in place of a link.
+	LinkExist ConflictKind = iota + 1
+	// LinkNotExpand means a file exists in place of a link and is not a directory,
+	// so it can't be expanded.
+	LinkNotExpand
+	// TargetNotExist means a target doesn't exist.
+	TargetNotExist
+	// TargetSpecial means a target is a special file.
+	TargetSpecial
+	// TargetNotExpand means a file exists in place of a link,
+	// but the target is not a directory, so it can't be expanded.
+	TargetNotExpand
+)
+
+func (k ConflictKind) String() string {
+	if err := k.err(); err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("ConflictKind(%d)", int(k))
+}
+
+func (k ConflictKind) err() error {
+	switch k {
+	case LinkExist:
+		return ErrLinkExist
+	case LinkNotExpand:
+		return ErrLinkNotExpand
+	case TargetNotExist:
+		return ErrTargetNotExist
+	case TargetSpecial:
+		return ErrTargetSpecial
+	case TargetNotExpand:
+		return ErrTargetNotExpand
+	default:
+		return nil
+	}
+}
+
+// Conflict is the error for a node that can't be symlinked. When using errors.Is,
+// it matches the error of its kind, e.g. ErrLinkExist for LinkExist.
+type Conflict struct {
+	Node *parser.Node
+	Kind ConflictKind
+	// Path is the full path of either the target or the link, depending on Kind.
+	Path string
+	// Existing is information about the file in place of the link or, when
+	// the target doesn't exist or is a special file, about the target.
+	Existing fs.FileInfo
+}
+
+func (c *Conflict) Error() string {
+	return fmt.Sprintf("linker: %s: %v", c.Path, c.Kind)
+}
+
+// Unwrap returns the error of the conflict's kind.
+func (c *Conflict) Unwrap() error { return c.Kind.err() }
+
+// Hint suggests how to fix the conflict. Conflicts of the same kind have the same hint.
+func (c *Conflict) Hint() string {
+	switch c.Kind {
+	case LinkExist:
+		return "remove symlinks that point to other files, or choose other link names with \"plg config -link\""
+	case LinkNotExpand:
+		return "move or remove files in place of links, since only directories can be expanded"
+	case TargetNotExist:
+		return "create missing targets or remove them from the configuration file"
+	case TargetSpecial:
+		return "remove special files, like named pipes and sockets, from the configuration file"
+	case TargetNotExpand:
+		return "move or remove files in place of links, or choose other link names with \"plg config -link\""
+	default:
+		return ""
+	}
+}
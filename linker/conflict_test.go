@@ -0,0 +1,120 @@
+package linker_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/fs"
+	"github.com/gbrlsnchs/pilgo/fs/fstest"
+	"github.com/gbrlsnchs/pilgo/linker"
+	"github.com/gbrlsnchs/pilgo/parser"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConflict(t *testing.T) {
+	drv := &fstest.InMemoryDriver{Files: map[string]fstest.File{
+		"dotfiles": {Children: map[string]fstest.File{
+			"exist":     {Data: []byte("exist")},
+			"expand":    {Children: map[string]fstest.File{}},
+			"notexpand": {Data: []byte("notexpand")},
+		}},
+		"home": {Children: map[string]fstest.File{
+			"exist":     {Linkname: filepath.Join("dotfiles", "expand")},
+			"expand":    {Data: []byte("expand")},
+			"notexpand": {Children: map[string]fstest.File{}},
+		}},
+	}}
+	node := func(name string) *parser.Node {
+		return &parser.Node{
+			Target: parser.File{BaseDir: "dotfiles", Path: []string{name}},
+			Link:   parser.File{BaseDir: "home", Path: []string{name}},
+		}
+	}
+	type conflict struct {
+		Kind     linker.ConflictKind
+		Path     string
+		Exists   bool
+		Linkname string
+		Err      error
+	}
+	testCases := []struct {
+		n    *parser.Node
+		want conflict
+	}{
+		{
+			n: node("exist"),
+			want: conflict{
+				Kind:     linker.LinkExist,
+				Path:     filepath.Join("home", "exist"),
+				Exists:   true,
+				Linkname: filepath.Join("dotfiles", "expand"),
+				Err:      linker.ErrLinkExist,
+			},
+		},
+		{
+			n: node("expand"),
+			want: conflict{
+				Kind:   linker.LinkNotExpand,
+				Path:   filepath.Join("home", "expand"),
+				Exists: true,
+				Err:    linker.ErrLinkNotExpand,
+			},
+		},
+		{
+			n: node("nonexistent"),
+			want: conflict{
+				Kind:   linker.TargetNotExist,
+				Path:   filepath.Join("dotfiles", "nonexistent"),
+				Exists: false,
+				Err:    linker.ErrTargetNotExist,
+			},
+		},
+		{
+			n: node("notexpand"),
+			want: conflict{
+				Kind:   linker.TargetNotExpand,
+				Path:   filepath.Join("dotfiles", "notexpand"),
+				Exists: true,
+				Err:    linker.ErrTargetNotExpand,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.want.Kind.String(), func(t *testing.T) {
+			ln := linker.New(fs.New(drv))
+			err := ln.Resolve(&parser.Tree{Root: &parser.Node{Children: []*parser.Node{tc.n}}})
+			var cft *linker.ConflictError
+			if !errors.As(err, &cft) || len(cft.Errs) != 1 {
+				t.Fatalf("want a single conflict, got %v", err)
+			}
+			var c *linker.Conflict
+			if !errors.As(cft.Errs[0], &c) {
+				t.Fatalf("want %T, got %v", c, cft.Errs[0])
+			}
+			if want, got := tc.n, c.Node; got != want {
+				t.Errorf("want node %p, got %p", want, got)
+			}
+			got := conflict{
+				Kind:     c.Kind,
+				Path:     c.Path,
+				Exists:   c.Existing.Exists(),
+				Linkname: c.Existing.Linkname(),
+			}
+			if want := tc.want.Err; !errors.Is(c, want) {
+				t.Errorf("want %v, got %v", want, c)
+			}
+			want := tc.want
+			want.Err = nil
+			if !cmp.Equal(got, want) {
+				t.Errorf("(-want +got):\n%s", cmp.Diff(want, got))
+			}
+			if want, got := "linker: "+tc.want.Path+": "+tc.want.Err.Error(), c.Error(); got != want {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			if c.Hint() == "" {
+				t.Error("want a hint, got none")
+			}
+		})
+	}
+}
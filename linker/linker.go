@@ -3,6 +3,8 @@ package linker
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"sync"
 
 	"github.com/gbrlsnchs/pilgo/fs"
 	"github.com/gbrlsnchs/pilgo/parser"
@@ -15,23 +17,48 @@ var (
 	ErrLinkNotExpand = errors.New("file exists in place of link and is not expandable")
 	// ErrTargetNotExist means a target doesn't exist and thus can't be symlinked.
 	ErrTargetNotExist = errors.New("target doesn't exist")
+	// ErrTargetSpecial means a target is a special file, like a named pipe or a socket,
+	// and thus can't be symlinked.
+	ErrTargetSpecial = errors.New("target is a special file")
 	// ErrTargetNotExpand means a target is not a directory and therefore can't be expanded.
 	ErrTargetNotExpand = errors.New("target can't be expanded")
 )
 
 // Linker is a file symlinker.
 type Linker struct {
-	fs fs.FileSystem
+	fs          fs.FileSystem
+	concurrency int
+}
+
+// Option is a functional option for a linker.
+type Option func(*Linker)
+
+// Concurrency sets how many nodes can be resolved at the same time. The default is 1,
+// which resolves nodes one by one. The linker's file system must be safe for
+// concurrent use when n is greater than 1.
+//
+// Results are the same regardless of n, except for when resolving fails with an error
+// other than a conflict, since nodes that would come later may have been resolved already.
+func Concurrency(n int) Option {
+	return func(ln *Linker) { ln.concurrency = n }
 }
 
 // New creates a new linker with a given file system.
-func New(fs fs.FileSystem) *Linker { return &Linker{fs} }
+func New(fs fs.FileSystem, opts ...Option) *Linker {
+	ln := &Linker{fs: fs, concurrency: 1}
+	for _, opt := range opts {
+		opt(ln)
+	}
+	return ln
+}
 
 // Link creates every symlink needed in tr. Before creating any symlinks,
 // it resolves nodes and checks for conflicts. If any conflicts or errors
 // are found, it aborts the operation.
 //
 // Also, if needed, it creates parent directories if those don't already exist.
+// If creating any of the symlinks or directories fails, the ones already created
+// are removed before returning the error.
 func (ln *Linker) Link(tr *parser.Tree) error {
 	err := ln.Resolve(tr)
 	if err != nil {
@@ -49,38 +76,88 @@ func (ln *Linker) Link(tr *parser.Tree) error {
 	if err := tr.Walk(prepare); err != nil {
 		return err
 	}
+	var created []string
 	for _, link := range links {
 		tgpath := link[0]
 		lnpath := link[1]
 		parent := lnpath.Dir()
+		dirs, err := ln.missingDirs(parent)
+		if err != nil {
+			return ln.rollback(created, err)
+		}
 		if err := ln.fs.MkdirAll(parent); err != nil {
-			return err
+			// Some of the directories may have been created before failing.
+			return ln.rollback(append(created, ln.existing(dirs)...), err)
 		}
+		created = append(created, dirs...)
 		if err := ln.fs.Symlink(tgpath.FullPath(), lnpath.FullPath()); err != nil {
-			return err
+			return ln.rollback(created, err)
 		}
+		created = append(created, lnpath.FullPath())
 	}
 	return nil
 }
 
-// Resolve checks and resolves nodes in a parsed tree.
+// missingDirs lists dirname and its parents that don't exist yet, from the outermost one.
+func (ln *Linker) missingDirs(dirname string) ([]string, error) {
+	var dirs []string
+	for {
+		fi, err := ln.fs.Stat(dirname)
+		if err != nil {
+			return nil, err
+		}
+		if fi.Exists() {
+			break
+		}
+		dirs = append([]string{dirname}, dirs...)
+		parent := filepath.Dir(dirname)
+		if parent == dirname {
+			break
+		}
+		dirname = parent
+	}
+	return dirs, nil
+}
+
+// existing returns which of names exist. Files that can't be checked are left out.
+func (ln *Linker) existing(names []string) []string {
+	var found []string
+	for _, name := range names {
+		if fi, err := ln.fs.Stat(name); err == nil && fi.Exists() {
+			found = append(found, name)
+		}
+	}
+	return found
+}
+
+// rollback removes created files in reverse order, so that a failed
+// link doesn't leave only some of the symlinks behind.
+func (ln *Linker) rollback(created []string, err error) error {
+	for i := len(created) - 1; i >= 0; i-- {
+		if rerr := ln.fs.Remove(created[i]); rerr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rerr)
+		}
+	}
+	return err
+}
+
+// Resolve checks and resolves nodes in a parsed tree. Conflicts are
+// collected in the same order nodes are walked. Nodes marked with
+// parser.StatusDuplicate are left untouched.
 func (ln *Linker) Resolve(tr *parser.Tree) error {
+	resolve := ln.resolve
+	if ln.concurrency > 1 {
+		errs := ln.resolveConcurrently(tr)
+		resolve = func(n *parser.Node) error { return errs[n] }
+	}
 	cft := new(ConflictError)
 	err := tr.Walk(func(n *parser.Node) error {
-		err := ln.resolve(n)
-		switch {
-		case errors.Is(err, ErrLinkExist):
-			fallthrough
-		case errors.Is(err, ErrLinkNotExpand):
-			fallthrough
-		case errors.Is(err, ErrTargetNotExist):
-			fallthrough
-		case errors.Is(err, ErrTargetNotExpand):
+		err := resolve(n)
+		if isConflict(err) {
 			cft.Errs = append(cft.Errs, err)
 			return nil
-		default:
-			return err
 		}
+		return err
 	})
 	if err != nil {
 		return err
@@ -91,7 +168,49 @@ func (ln *Linker) Resolve(tr *parser.Tree) error {
 	return nil
 }
 
+// resolveConcurrently resolves nodes using a bounded number of goroutines and
+// returns errors by node. Children are only resolved once their parent is, since
+// resolving a node may expand it. Like when walking the tree, children of nodes
+// that fail with errors other than conflicts are not resolved.
+func (ln *Linker) resolveConcurrently(tr *parser.Tree) map[*parser.Node]error {
+	var (
+		mu    sync.Mutex
+		errs  = make(map[*parser.Node]error)
+		sem   = make(chan struct{}, ln.concurrency)
+		wg    sync.WaitGroup
+		visit func(*parser.Node)
+	)
+	visit = func(n *parser.Node) {
+		defer wg.Done()
+		sem <- struct{}{}
+		err := ln.resolve(n)
+		<-sem
+		if err != nil {
+			mu.Lock()
+			errs[n] = err
+			mu.Unlock()
+			if !isConflict(err) {
+				return
+			}
+		}
+		for _, c := range n.Children {
+			wg.Add(1)
+			go visit(c)
+		}
+	}
+	for _, n := range tr.Root.Children {
+		wg.Add(1)
+		go visit(n)
+	}
+	wg.Wait()
+	return errs
+}
+
 func (ln *Linker) resolve(n *parser.Node) error {
+	// Nodes with colliding links have already been reported by (*parser.Tree).Validate.
+	if n.Status == parser.StatusDuplicate {
+		return nil
+	}
 	tgpath := n.Target.FullPath()
 	target, err := ln.fs.Stat(tgpath)
 	if err != nil {
@@ -99,7 +218,11 @@ func (ln *Linker) resolve(n *parser.Node) error {
 	}
 	if !target.Exists() {
 		n.Status = parser.StatusError
-		return errWithPath(tgpath, ErrTargetNotExist)
+		return &Conflict{n, TargetNotExist, tgpath, target}
+	}
+	if fs.IsSpecial(target) {
+		n.Status = parser.StatusError
+		return &Conflict{n, TargetSpecial, tgpath, target}
 	}
 	if len(n.Children) > 0 || len(n.Link.Path) == 0 {
 		n.Status = parser.StatusSkip
@@ -120,15 +243,15 @@ func (ln *Linker) resolve(n *parser.Node) error {
 			return nil
 		}
 		n.Status = parser.StatusConflict
-		return errWithPath(lnpath, ErrLinkExist)
+		return &Conflict{n, LinkExist, lnpath, link}
 	}
 	if !target.IsDir() {
 		n.Status = parser.StatusConflict
-		return errWithPath(tgpath, ErrTargetNotExpand)
+		return &Conflict{n, TargetNotExpand, tgpath, link}
 	}
 	if !link.IsDir() {
 		n.Status = parser.StatusConflict
-		return errWithPath(lnpath, ErrLinkNotExpand)
+		return &Conflict{n, LinkNotExpand, lnpath, link}
 	}
 	children, err := ln.fs.ReadDir(tgpath)
 	if err != nil {
@@ -157,8 +280,12 @@ func expand(n *parser.Node, children []fs.FileInfo) {
 				Path:    append(ln, c.Name()),
 			},
 			Children: nil,
+			Vars:     n.Vars,
 		}
 	}
 }
 
-func errWithPath(path string, err error) error { return fmt.Errorf("linker: %s: %w", path, err) }
+func isConflict(err error) bool {
+	var c *Conflict
+	return errors.As(err, &c)
+}
@@ -2,8 +2,13 @@ package linker_test
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/gbrlsnchs/pilgo/fs"
 	"github.com/gbrlsnchs/pilgo/fs/fstest"
@@ -14,7 +19,10 @@ import (
 
 func TestLinker(t *testing.T) {
 	t.Run("Link", testLink)
+	t.Run("LinkFault", testLinkFault)
 	t.Run("Resolve", testResolve)
+	t.Run("ResolveConcurrency", testResolveConcurrency)
+	t.Run("SymlinkedDir", testSymlinkedDir)
 }
 
 func testLink(t *testing.T) {
@@ -31,6 +39,10 @@ func testLink(t *testing.T) {
 		{
 			drv: fstest.SpyDriver{
 				StatReturn: map[string]fs.FileInfo{
+					"test": fstest.StubFile{
+						ExistsReturn: true,
+						IsDirReturn:  true,
+					},
 					"foo": fstest.StubFile{
 						ExistsReturn: true,
 					},
@@ -69,6 +81,10 @@ func testLink(t *testing.T) {
 		{
 			drv: fstest.SpyDriver{
 				StatReturn: map[string]fs.FileInfo{
+					"test": fstest.StubFile{
+						ExistsReturn: true,
+						IsDirReturn:  true,
+					},
 					"foo": fstest.StubFile{
 						ExistsReturn: true,
 					},
@@ -241,6 +257,10 @@ func testLink(t *testing.T) {
 					"expand": nil,
 				},
 				StatReturn: map[string]fs.FileInfo{
+					"test": fstest.StubFile{
+						ExistsReturn: true,
+						IsDirReturn:  true,
+					},
 					// done
 					"done": fstest.StubFile{
 						ExistsReturn: true,
@@ -340,7 +360,8 @@ func testLink(t *testing.T) {
 			fs := fs.New(&tc.drv)
 			ln := linker.New(fs)
 			err := ln.Link(tc.tr)
-			if !errors.As(err, &tc.err) {
+			var cft *linker.ConflictError
+			if !errors.As(err, &cft) {
 				if want, got := tc.err, err; !errors.Is(got, want) {
 					t.Fatalf("want %v, got %v", want, got)
 				}
@@ -367,6 +388,93 @@ func testLink(t *testing.T) {
 	}
 }
 
+func testLinkFault(t *testing.T) {
+	newFiles := func() map[string]fstest.File {
+		return map[string]fstest.File{
+			"dotfiles": {Children: map[string]fstest.File{
+				"foo": {Data: []byte("foo")},
+				"bar": {Data: []byte("bar")},
+				"baz": {Data: []byte("baz")},
+			}},
+			"home": {Children: map[string]fstest.File{}},
+		}
+	}
+	node := func(name string, link ...string) *parser.Node {
+		return &parser.Node{
+			Target: parser.File{BaseDir: "dotfiles", Path: []string{name}},
+			Link:   parser.File{BaseDir: "home", Path: link},
+		}
+	}
+	testCases := []struct {
+		name  string
+		rules []fstest.FaultRule
+		err   error
+		// Files left behind when rolling back fails.
+		remaining []string
+	}{
+		{
+			name:      "Nth symlink",
+			rules:     []fstest.FaultRule{{Method: "Symlink", N: 3, Err: syscall.EACCES}},
+			err:       syscall.EACCES,
+			remaining: nil,
+		},
+		{
+			name:      "mkdir",
+			rules:     []fstest.FaultRule{{Method: "MkdirAll", Path: "c", Err: syscall.ENOSPC}},
+			err:       syscall.ENOSPC,
+			remaining: nil,
+		},
+		{
+			name:      "mkdir after first level",
+			rules:     []fstest.FaultRule{{Method: "MkdirAll", Path: "b", Err: syscall.ENOSPC}},
+			err:       syscall.ENOSPC,
+			remaining: nil,
+		},
+		{
+			name: "rollback",
+			rules: []fstest.FaultRule{
+				{Method: "Symlink", N: 3, Err: syscall.EACCES},
+				{Method: "Remove", Path: "bar", Err: syscall.EPERM},
+			},
+			err: syscall.EACCES,
+			remaining: []string{
+				filepath.Join("home", "foo"),
+				filepath.Join("home", "a", "b", "bar"),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			drv := &fstest.InMemoryDriver{Files: newFiles()}
+			ln := linker.New(fs.New(&fstest.FaultDriver{Driver: drv, Rules: tc.rules}))
+			tr := &parser.Tree{Root: &parser.Node{Children: []*parser.Node{
+				node("foo", "foo"),
+				node("bar", "a", "b", "bar"),
+				node("baz", "c", "baz"),
+			}}}
+			err := ln.Link(tr)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if len(tc.remaining) > 0 {
+				for _, name := range tc.remaining {
+					fi, err := drv.Stat(name)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !fi.Exists() {
+						t.Errorf("want %q to still exist", name)
+					}
+				}
+				return
+			}
+			if want, got := newFiles(), drv.Files; !cmp.Equal(got, want) {
+				t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
 func testResolve(t *testing.T) {
 	testCases := []struct {
 		drv       fstest.SpyDriver
@@ -653,6 +761,48 @@ func testResolve(t *testing.T) {
 				Status:   parser.StatusError,
 			},
 		},
+		{
+			drv: fstest.SpyDriver{
+				StatReturn: map[string]fs.FileInfo{
+					"foo": fstest.StubFile{
+						ExistsReturn: true,
+						ModeReturn:   os.ModeNamedPipe | 0o644,
+					},
+					filepath.Join("test", "foo"): fstest.StubFile{
+						ExistsReturn: false,
+					},
+				},
+				StatErr: map[string]error{
+					"foo":                        nil,
+					filepath.Join("test", "foo"): nil,
+				},
+			},
+			n: &parser.Node{
+				Target: parser.File{
+					BaseDir: "",
+					Path:    []string{"foo"},
+				},
+				Link: parser.File{
+					BaseDir: "test",
+					Path:    []string{"foo"},
+				},
+				Children: nil,
+			},
+			err:       (*linker.ConflictError)(nil),
+			conflicts: []error{linker.ErrTargetSpecial},
+			want: &parser.Node{
+				Target: parser.File{
+					BaseDir: "",
+					Path:    []string{"foo"},
+				},
+				Link: parser.File{
+					BaseDir: "test",
+					Path:    []string{"foo"},
+				},
+				Children: nil,
+				Status:   parser.StatusError,
+			},
+		},
 		{
 			drv: fstest.SpyDriver{
 				StatReturn: map[string]fs.FileInfo{
@@ -1049,8 +1199,8 @@ func testResolve(t *testing.T) {
 			}
 			err := ln.Resolve(tr)
 			// TODO(gbrlsnchs): check error message has correct file path
-			if errors.As(err, &tc.err) {
-				cft := tc.err.(*linker.ConflictError)
+			var cft *linker.ConflictError
+			if errors.As(err, &cft) {
 				if want, got := len(tc.conflicts), len(cft.Errs); got != want {
 					t.Fatalf("want %d, got %d", want, got)
 				}
@@ -1076,3 +1226,245 @@ func testResolve(t *testing.T) {
 		})
 	}
 }
+
+// syntheticTree creates a file system with dirs directories of files files each
+// to be symlinked, and a function that parses a tree for them. Depending on its
+// index, a directory is either expanded, ready to be symlinked, in conflict
+// with a regular file or doesn't exist.
+func syntheticTree(dirs, files int) (*fstest.InMemoryDriver, func() *parser.Tree) {
+	var (
+		dotfiles = make(map[string]fstest.File, dirs)
+		home     = make(map[string]fstest.File, dirs)
+	)
+	for i := 0; i < dirs; i++ {
+		name := fmt.Sprintf("dir%04d", i)
+		children := make(map[string]fstest.File, files)
+		for j := 0; j < files; j++ {
+			children[fmt.Sprintf("file%04d", j)] = fstest.File{Data: []byte(name)}
+		}
+		switch i % 4 {
+		case 0:
+			home[name] = fstest.File{Children: map[string]fstest.File{}}
+		case 1:
+			// Ready, since it doesn't exist in home.
+		case 2:
+			home[name] = fstest.File{Data: []byte("conflict")}
+		case 3:
+			continue
+		}
+		dotfiles[name] = fstest.File{Children: children}
+	}
+	drv := &fstest.InMemoryDriver{Files: map[string]fstest.File{
+		"dotfiles": {Children: dotfiles},
+		"home":     {Children: home},
+	}}
+	return drv, func() *parser.Tree {
+		nodes := make([]*parser.Node, dirs)
+		for i := range nodes {
+			name := fmt.Sprintf("dir%04d", i)
+			nodes[i] = &parser.Node{
+				Target: parser.File{BaseDir: "dotfiles", Path: []string{name}},
+				Link:   parser.File{BaseDir: "home", Path: []string{name}},
+			}
+		}
+		return &parser.Tree{Root: &parser.Node{Children: nodes}}
+	}
+}
+
+func testResolveConcurrency(t *testing.T) {
+	drv, newTree := syntheticTree(64, 16)
+	resolve := func(opts ...linker.Option) (*parser.Tree, []string) {
+		tr := newTree()
+		err := linker.New(fs.New(drv), opts...).Resolve(tr)
+		var cft *linker.ConflictError
+		if !errors.As(err, &cft) {
+			t.Fatalf("want %T, got %v", cft, err)
+		}
+		errs := make([]string, len(cft.Errs))
+		for i, err := range cft.Errs {
+			errs[i] = err.Error()
+		}
+		return tr, errs
+	}
+	wantTree, wantErrs := resolve()
+	for _, n := range []int{2, 8, 64} {
+		t.Run(strconv.Itoa(n), func(t *testing.T) {
+			tr, errs := resolve(linker.Concurrency(n))
+			if want, got := wantErrs, errs; !cmp.Equal(got, want) {
+				t.Fatalf("conflicts mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			if want, got := wantTree, tr; !cmp.Equal(got, want) {
+				t.Fatalf("tree mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+	t.Run("error", func(t *testing.T) {
+		errStat := errors.New("stat")
+		fdrv := &fstest.FaultDriver{
+			Driver: drv,
+			Rules:  []fstest.FaultRule{{Method: "Stat", Path: "file0008", Err: errStat}},
+		}
+		err := linker.New(fs.New(fdrv), linker.Concurrency(8)).Resolve(newTree())
+		if want, got := errStat, err; !errors.Is(got, want) {
+			t.Fatalf("want %v, got %v", want, got)
+		}
+	})
+}
+
+// slowDriver simulates a network file system, in which reading information about files is slow.
+type slowDriver struct {
+	fs.Driver
+}
+
+func (drv slowDriver) ReadDir(dirname string) ([]fs.FileInfo, error) {
+	time.Sleep(100 * time.Microsecond)
+	return drv.Driver.ReadDir(dirname)
+}
+
+func (drv slowDriver) Stat(filename string) (fs.FileInfo, error) {
+	time.Sleep(100 * time.Microsecond)
+	return drv.Driver.Stat(filename)
+}
+
+func BenchmarkResolve(b *testing.B) {
+	drv, newTree := syntheticTree(256, 16)
+	for _, n := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("Concurrency=%d", n), func(b *testing.B) {
+			ln := linker.New(fs.New(slowDriver{drv}), linker.Concurrency(n))
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				tr := newTree()
+				b.StartTimer()
+				var cft *linker.ConflictError
+				if err := ln.Resolve(tr); !errors.As(err, &cft) {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func testSymlinkedDir(t *testing.T) {
+	dotfiles := map[string]fstest.File{
+		"dotfiles": {Children: map[string]fstest.File{
+			"config": {Children: map[string]fstest.File{
+				"nvim": {Children: map[string]fstest.File{
+					"init.vim": {Data: []byte("init.vim")},
+				}},
+				"zsh": {Data: []byte("zsh")},
+			}},
+		}},
+	}
+	node := func(children ...*parser.Node) *parser.Node {
+		return &parser.Node{
+			Target:   parser.File{BaseDir: "dotfiles", Path: []string{"config"}},
+			Link:     parser.File{BaseDir: "home", Path: []string{".config"}},
+			Children: children,
+		}
+	}
+	child := func(status parser.Status, path ...string) *parser.Node {
+		return &parser.Node{
+			Target: parser.File{BaseDir: "dotfiles", Path: append([]string{"config"}, path...)},
+			Link:   parser.File{BaseDir: "home", Path: append([]string{".config"}, path...)},
+			Status: status,
+		}
+	}
+	testCases := []struct {
+		name   string
+		home   map[string]fstest.File
+		n      *parser.Node
+		want   *parser.Node
+		err    error
+		linked []string
+	}{
+		{
+			name: "done",
+			home: map[string]fstest.File{
+				".config": {Linkname: ".dotconfig"},
+				".dotconfig": {Children: map[string]fstest.File{
+					"nvim": {Linkname: filepath.Join("dotfiles", "config", "nvim")},
+				}},
+			},
+			n: node(child(0, "nvim"), child(0, "zsh")),
+			want: func() *parser.Node {
+				n := node(
+					child(parser.StatusDone, "nvim"),
+					child(parser.StatusReady, "zsh"),
+				)
+				n.Status = parser.StatusSkip
+				return n
+			}(),
+			err:    nil,
+			linked: []string{filepath.Join("home", ".dotconfig", "zsh")},
+		},
+		{
+			name: "expand",
+			home: map[string]fstest.File{
+				".config": {Linkname: ".dotconfig"},
+				".dotconfig": {Children: map[string]fstest.File{
+					"nvim": {Children: map[string]fstest.File{}},
+				}},
+			},
+			n: node(child(0, "nvim")),
+			want: func() *parser.Node {
+				nvim := child(parser.StatusExpand, "nvim")
+				nvim.Children = []*parser.Node{child(parser.StatusReady, "nvim", "init.vim")}
+				n := node(nvim)
+				n.Status = parser.StatusSkip
+				return n
+			}(),
+			err:    nil,
+			linked: []string{filepath.Join("home", ".dotconfig", "nvim", "init.vim")},
+		},
+		{
+			name: "loop",
+			home: map[string]fstest.File{
+				".config": {Linkname: ".cfg"},
+				".cfg":    {Linkname: ".config"},
+			},
+			n: node(child(0, "nvim")),
+			want: func() *parser.Node {
+				n := node(child(0, "nvim"))
+				n.Status = parser.StatusSkip
+				return n
+			}(),
+			err:    fs.ErrTooManyLinks,
+			linked: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := map[string]fstest.File{"home": {Children: tc.home}}
+			for name, f := range dotfiles {
+				files[name] = f
+			}
+			drv := &fstest.InMemoryDriver{Files: files}
+			ln := linker.New(fs.New(drv))
+			tr := &parser.Tree{
+				Root: &parser.Node{Children: []*parser.Node{tc.n}},
+			}
+			err := ln.Resolve(tr)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if want, got := tc.want, tc.n; !cmp.Equal(got, want) {
+				t.Fatalf("(*Linker).Resolve mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			if err != nil {
+				return
+			}
+			if err := ln.Link(tr); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tc.linked {
+				fi, err := drv.Stat(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if fi.Linkname() == "" {
+					t.Errorf("want %q to be a symlink", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrUnsetVar means a variable is not set and has no default value.
+	ErrUnsetVar = errors.New("unset variable")
+	// ErrBadSubst means a variable is not referenced correctly, e.g. "${FOO".
+	ErrBadSubst = errors.New("bad substitution")
+)
+
+// expander expands variables and, for paths, a leading "~" similarly to a shell.
+// Supported variable forms are $VAR, ${VAR}, ${VAR:-default} and ${VAR:?message},
+// while "$$" is a literal "$".
+type expander struct {
+	lookupEnv  func(string) (string, bool)
+	lookupUser func(string) (*user.User, error)
+	homeDir    string
+}
+
+// expandPath expands a leading "~" or "~user" and then variables in s.
+// Like in a shell, "~user" is left as is if the user doesn't exist.
+func (e expander) expandPath(s string) (string, error) {
+	if strings.HasPrefix(s, "~") {
+		i := strings.IndexFunc(s, isSeparator)
+		if i < 0 {
+			i = len(s)
+		}
+		dir := e.homeDir
+		if name := s[1:i]; name != "" {
+			dir = ""
+			if u, err := e.lookupUser(name); err == nil {
+				dir = u.HomeDir
+			}
+		}
+		if dir != "" {
+			s = filepath.Join(dir, s[i:])
+		}
+	}
+	return e.expand(s)
+}
+
+// expand expands variables in s.
+func (e expander) expand(s string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		s = s[i+1:]
+		switch {
+		case strings.HasPrefix(s, "$"):
+			b.WriteByte('$')
+			s = s[1:]
+		case strings.HasPrefix(s, "{"):
+			end := closingBrace(s)
+			if end < 0 {
+				return "", fmt.Errorf("%w %q", ErrBadSubst, "$"+s)
+			}
+			v, err := e.expandBraces(s[1:end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			s = s[end+1:]
+		default:
+			n := nameLen(s)
+			if n == 0 {
+				// Not a variable, e.g. "$1" or a trailing "$".
+				b.WriteByte('$')
+				continue
+			}
+			v, ok := e.lookupEnv(s[:n])
+			if !ok {
+				return "", fmt.Errorf("%w %q", ErrUnsetVar, s[:n])
+			}
+			b.WriteString(v)
+			s = s[n:]
+		}
+	}
+}
+
+// expandBraces expands the content of "${...}".
+func (e expander) expandBraces(s string) (string, error) {
+	n := nameLen(s)
+	if n == 0 {
+		return "", fmt.Errorf("%w %q", ErrBadSubst, "${"+s+"}")
+	}
+	name, op := s[:n], s[n:]
+	v, ok := e.lookupEnv(name)
+	switch {
+	case op == "":
+		if !ok {
+			return "", fmt.Errorf("%w %q", ErrUnsetVar, name)
+		}
+		return v, nil
+	case strings.HasPrefix(op, ":-"):
+		if v != "" {
+			return v, nil
+		}
+		return e.expand(op[2:])
+	case strings.HasPrefix(op, ":?"):
+		if v != "" {
+			return v, nil
+		}
+		msg, err := e.expand(op[2:])
+		if err != nil {
+			return "", err
+		}
+		if msg == "" {
+			return "", fmt.Errorf("%w %q", ErrUnsetVar, name)
+		}
+		return "", fmt.Errorf("%w %q: %s", ErrUnsetVar, name, msg)
+	}
+	return "", fmt.Errorf("%w %q", ErrBadSubst, "${"+s+"}")
+}
+
+// closingBrace returns the index of the brace that closes the one s starts with,
+// taking nested references into account, or -1 if there's none.
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// nameLen returns the length of the variable name s starts with.
+func nameLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9' {
+			continue
+		}
+		return i
+	}
+	return len(s)
+}
+
+func isSeparator(r rune) bool { return r == '/' || r == filepath.Separator }
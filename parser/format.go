@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gbrlsnchs/pilgo/parser/internal/treewriter"
+)
+
+var (
+	// ErrUnknownFormat means a format name couldn't be recognized.
+	ErrUnknownFormat = errors.New("unknown format")
+	// ErrUnknownCharset means a charset name couldn't be recognized.
+	ErrUnknownCharset = errors.New("unknown charset")
+)
+
+// Format is the format used to print a tree.
+type Format uint8
+
+const (
+	// FormatTree prints a tree in a tree view.
+	FormatTree Format = iota
+	// FormatDOT prints a tree as a Graphviz graph.
+	FormatDOT
+	// FormatMermaid prints a tree as a Mermaid flowchart.
+	FormatMermaid
+)
+
+var formatNames = map[Format]string{
+	FormatTree:    "tree",
+	FormatDOT:     "dot",
+	FormatMermaid: "mermaid",
+}
+
+// ParseFormat returns the format represented by s, regardless of its case.
+func ParseFormat(s string) (Format, error) {
+	name := strings.ToLower(s)
+	for f, fname := range formatNames {
+		if fname == name {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("parser: %w %q", ErrUnknownFormat, s)
+}
+
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return "undefined"
+}
+
+// Charset is the set of characters used to draw a tree view.
+type Charset uint8
+
+const (
+	// CharsetUnicode draws a tree view using box-drawing characters.
+	CharsetUnicode Charset = iota
+	// CharsetASCII draws a tree view using only ASCII characters.
+	CharsetASCII
+)
+
+var charsets = map[Charset]struct {
+	name   string
+	glyphs treewriter.Glyphs
+}{
+	CharsetUnicode: {"unicode", treewriter.UnicodeGlyphs},
+	CharsetASCII:   {"ascii", treewriter.ASCIIGlyphs},
+}
+
+// ParseCharset returns the charset represented by s, regardless of its case.
+func ParseCharset(s string) (Charset, error) {
+	name := strings.ToLower(s)
+	for cs, info := range charsets {
+		if info.name == name {
+			return cs, nil
+		}
+	}
+	return 0, fmt.Errorf("parser: %w %q", ErrUnknownCharset, s)
+}
+
+func (cs Charset) String() string {
+	if info, ok := charsets[cs]; ok {
+		return info.name
+	}
+	return "undefined"
+}
+
+// PrintOption is a functional option that modifies how a tree is printed.
+type PrintOption func(*printer)
+
+type printer struct {
+	format       Format
+	charset      Charset
+	maxDepth     int
+	collapseDone bool
+}
+
+// OutputFormat sets the format used to print a tree.
+func OutputFormat(f Format) PrintOption {
+	return func(p *printer) {
+		p.format = f
+	}
+}
+
+// TreeCharset sets the charset used to draw a tree view.
+func TreeCharset(cs Charset) PrintOption {
+	return func(p *printer) {
+		p.charset = cs
+	}
+}
+
+// MaxDepth limits how deep a tree view is printed. Omitted nodes are counted
+// and summarized below their parents. Zero or negative values mean no limit.
+func MaxDepth(n int) PrintOption {
+	return func(p *printer) {
+		p.maxDepth = n
+	}
+}
+
+// CollapseDone folds nodes whose descendants are all already symlinked
+// into a single summary line in a tree view.
+func CollapseDone(p *printer) {
+	p.collapseDone = true
+}
@@ -0,0 +1,57 @@
+package parser_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/parser"
+)
+
+func TestParseFormat(t *testing.T) {
+	testCases := []struct {
+		s    string
+		want parser.Format
+		err  error
+	}{
+		{"tree", parser.FormatTree, nil},
+		{"DOT", parser.FormatDOT, nil},
+		{"Mermaid", parser.FormatMermaid, nil},
+		{"svg", 0, parser.ErrUnknownFormat},
+		{"", 0, parser.ErrUnknownFormat},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.s, func(t *testing.T) {
+			f, err := parser.ParseFormat(tc.s)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if want, got := tc.want, f; got != want {
+				t.Fatalf("want %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+func TestParseCharset(t *testing.T) {
+	testCases := []struct {
+		s    string
+		want parser.Charset
+		err  error
+	}{
+		{"unicode", parser.CharsetUnicode, nil},
+		{"ASCII", parser.CharsetASCII, nil},
+		{"latin1", 0, parser.ErrUnknownCharset},
+		{"", 0, parser.ErrUnknownCharset},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.s, func(t *testing.T) {
+			cs, err := parser.ParseCharset(tc.s)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if want, got := tc.want, cs; got != want {
+				t.Fatalf("want %s, got %s", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gbrlsnchs/pilgo/parser/internal/graphwriter"
+)
+
+var statusColors = map[Status]string{
+	StatusReady:     "blue",
+	StatusDone:      "green",
+	StatusConflict:  "orange",
+	StatusError:     "red",
+	StatusDuplicate: "purple",
+}
+
+type graphBuilder struct {
+	g        graphwriter.Graph
+	clusters map[string]int
+	ids      map[string]string
+}
+
+// buildGraph converts a tree into a graph with edges going from targets to links.
+// Files are grouped by their base directories.
+func buildGraph(tr *Tree) *graphwriter.Graph {
+	gb := graphBuilder{
+		clusters: make(map[string]int),
+		ids:      make(map[string]string),
+	}
+	tr.Walk(func(n *Node) error {
+		if n.Status&dullStatus != 0 || len(n.Target.Path) == 0 || len(n.Link.Path) == 0 {
+			return nil
+		}
+		color := statusColors[n.Status]
+		e := graphwriter.Edge{
+			From:  gb.add(n.Target, ""),
+			To:    gb.add(n.Link, color),
+			Color: color,
+		}
+		gb.g.Edges = append(gb.g.Edges, e)
+		return nil
+	})
+	return &gb.g
+}
+
+func (gb *graphBuilder) add(f File, color string) string {
+	fullPath := f.FullPath()
+	if id, ok := gb.ids[fullPath]; ok {
+		return id
+	}
+	label := f.BaseDir
+	if label == "" {
+		label = "."
+	}
+	i, ok := gb.clusters[label]
+	if !ok {
+		i = len(gb.g.Clusters)
+		gb.clusters[label] = i
+		gb.g.Clusters = append(gb.g.Clusters, graphwriter.Cluster{Label: label})
+	}
+	id := fmt.Sprintf("n%d", len(gb.ids))
+	gb.ids[fullPath] = id
+	c := &gb.g.Clusters[i]
+	c.Nodes = append(c.Nodes, graphwriter.Node{
+		ID:    id,
+		Label: filepath.Join(f.Path...),
+		Color: color,
+	})
+	return id
+}
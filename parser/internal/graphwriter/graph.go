@@ -0,0 +1,27 @@
+package graphwriter
+
+// Graph is a directed graph whose nodes are grouped in clusters.
+type Graph struct {
+	Clusters []Cluster
+	Edges    []Edge
+}
+
+// Cluster is a labeled group of nodes.
+type Cluster struct {
+	Label string
+	Nodes []Node
+}
+
+// Node is a graph node. Its ID must be unique across the whole graph.
+type Node struct {
+	ID    string
+	Label string
+	Color string
+}
+
+// Edge is a directed connection between two nodes, referenced by their IDs.
+type Edge struct {
+	From  string
+	To    string
+	Color string
+}
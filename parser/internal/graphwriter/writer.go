@@ -0,0 +1,84 @@
+package graphwriter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	dotEscaper     = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	mermaidEscaper = strings.NewReplacer(`"`, "#quot;")
+)
+
+// Writer prints a graph using a graph description language.
+type Writer struct {
+	w   io.Writer
+	g   *Graph
+	err error
+}
+
+// NewWriter stores the graph in the Writer.
+func NewWriter(w io.Writer, g *Graph) *Writer {
+	return &Writer{w: w, g: g}
+}
+
+// WriteDOT writes the graph in Graphviz's DOT language.
+func (w *Writer) WriteDOT() error {
+	w.printf("digraph pilgo {\n")
+	w.printf("\trankdir=LR;\n")
+	for i, c := range w.g.Clusters {
+		w.printf("\tsubgraph cluster_%d {\n", i)
+		w.printf("\t\tlabel=\"%s\";\n", dotEscaper.Replace(c.Label))
+		for _, n := range c.Nodes {
+			w.printf("\t\t%s [label=\"%s\"", n.ID, dotEscaper.Replace(n.Label))
+			if n.Color != "" {
+				w.printf(", color=\"%s\"", n.Color)
+			}
+			w.printf("];\n")
+		}
+		w.printf("\t}\n")
+	}
+	for _, e := range w.g.Edges {
+		w.printf("\t%s -> %s", e.From, e.To)
+		if e.Color != "" {
+			w.printf(" [color=\"%s\"]", e.Color)
+		}
+		w.printf(";\n")
+	}
+	w.printf("}\n")
+	return w.err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart.
+func (w *Writer) WriteMermaid() error {
+	w.printf("flowchart LR\n")
+	var styles []string
+	for i, c := range w.g.Clusters {
+		w.printf("\tsubgraph c%d [\"%s\"]\n", i, mermaidEscaper.Replace(c.Label))
+		for _, n := range c.Nodes {
+			w.printf("\t\t%s[\"%s\"]\n", n.ID, mermaidEscaper.Replace(n.Label))
+			if n.Color != "" {
+				styles = append(styles, fmt.Sprintf("style %s stroke:%s", n.ID, n.Color))
+			}
+		}
+		w.printf("\tend\n")
+	}
+	for i, e := range w.g.Edges {
+		w.printf("\t%s --> %s\n", e.From, e.To)
+		if e.Color != "" {
+			styles = append(styles, fmt.Sprintf("linkStyle %d stroke:%s", i, e.Color))
+		}
+	}
+	for _, s := range styles {
+		w.printf("\t%s\n", s)
+	}
+	return w.err
+}
+
+func (w *Writer) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, format, args...)
+}
@@ -0,0 +1,89 @@
+package graphwriter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/parser/internal/graphwriter"
+)
+
+func TestWriter(t *testing.T) {
+	g := &graphwriter.Graph{
+		Clusters: []graphwriter.Cluster{
+			{
+				Label: "dotfiles",
+				Nodes: []graphwriter.Node{
+					{ID: "n0", Label: "foo"},
+					{ID: "n2", Label: `"bar"`},
+				},
+			},
+			{
+				Label: "home",
+				Nodes: []graphwriter.Node{
+					{ID: "n1", Label: ".foo", Color: "green"},
+					{ID: "n3", Label: `"bar"`},
+				},
+			},
+		},
+		Edges: []graphwriter.Edge{
+			{From: "n0", To: "n1", Color: "green"},
+			{From: "n2", To: "n3"},
+		},
+	}
+	testCases := []struct {
+		name  string
+		write func(*graphwriter.Writer) error
+		want  string
+	}{
+		{
+			name:  "WriteDOT",
+			write: (*graphwriter.Writer).WriteDOT,
+			want: `digraph pilgo {
+	rankdir=LR;
+	subgraph cluster_0 {
+		label="dotfiles";
+		n0 [label="foo"];
+		n2 [label="\"bar\""];
+	}
+	subgraph cluster_1 {
+		label="home";
+		n1 [label=".foo", color="green"];
+		n3 [label="\"bar\""];
+	}
+	n0 -> n1 [color="green"];
+	n2 -> n3;
+}
+`,
+		},
+		{
+			name:  "WriteMermaid",
+			write: (*graphwriter.Writer).WriteMermaid,
+			want: `flowchart LR
+	subgraph c0 ["dotfiles"]
+		n0["foo"]
+		n2["#quot;bar#quot;"]
+	end
+	subgraph c1 ["home"]
+		n1[".foo"]
+		n3["#quot;bar#quot;"]
+	end
+	n0 --> n1
+	n2 --> n3
+	style n1 stroke:green
+	linkStyle 0 stroke:green
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var bd strings.Builder
+			if err := tc.write(graphwriter.NewWriter(&bd, g)); err != nil {
+				t.Fatal(err)
+			}
+			if want, got := tc.want, bd.String(); got != want {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			t.Logf("\n%s", bd.String())
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package treewriter
+
+// Glyphs is a set of characters used for drawing a tree.
+type Glyphs struct {
+	Branch     string // Branch connects a node that has siblings below it.
+	LastBranch string // LastBranch connects the last node of a level.
+	Vertical   string // Vertical continues a branch past nested levels.
+	Horizontal string // Horizontal leads from a branch to a node.
+	Ellipsis   string // Ellipsis marks omitted nodes.
+}
+
+var (
+	// UnicodeGlyphs draws a tree using box-drawing characters.
+	UnicodeGlyphs = Glyphs{
+		Branch:     "├",
+		LastBranch: "└",
+		Vertical:   "│",
+		Horizontal: "─",
+		Ellipsis:   "…",
+	}
+	// ASCIIGlyphs draws a tree using only ASCII characters.
+	ASCIIGlyphs = Glyphs{
+		Branch:     "|",
+		LastBranch: "`",
+		Vertical:   "|",
+		Horizontal: "-",
+		Ellipsis:   "...",
+	}
+)
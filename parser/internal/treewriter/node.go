@@ -4,4 +4,7 @@ package treewriter
 type Node interface {
 	At(int) Node
 	Len() int
+	// Elided returns how many descendants are omitted from the node.
+	// When positive, a marker is printed after the node's children.
+	Elided() int
 }
@@ -3,10 +3,12 @@ package treewriter_test
 import "github.com/gbrlsnchs/pilgo/parser/internal/treewriter"
 
 type testNode struct {
-	text  string
-	nodes []*testNode
+	text   string
+	nodes  []*testNode
+	elided int
 }
 
 func (tr *testNode) At(i int) treewriter.Node { return tr.nodes[i] }
 func (tr *testNode) Len() int                 { return len(tr.nodes) }
+func (tr *testNode) Elided() int              { return tr.elided }
 func (tr *testNode) String() string           { return tr.text }
@@ -3,17 +3,64 @@ package treewriter
 import (
 	"fmt"
 	"io"
+	"strings"
+)
+
+const (
+	defaultIndent = 4
+	minIndent     = 2
 )
 
 // Writer is a buffer that prints a tree.
 type Writer struct {
-	w    io.Writer
-	root Node
+	w        io.Writer
+	root     Node
+	glyphs   Glyphs
+	indent   int
+	maxDepth int
+}
+
+// Option is a functional option that modifies a Writer.
+type Option func(*Writer)
+
+// SetGlyphs sets the characters used for drawing the tree.
+func SetGlyphs(g Glyphs) Option {
+	return func(w *Writer) {
+		w.glyphs = g
+	}
+}
+
+// Indent sets how many columns each level of the tree is indented by.
+// Values smaller than 2 are rounded up to 2.
+func Indent(n int) Option {
+	return func(w *Writer) {
+		if n < minIndent {
+			n = minIndent
+		}
+		w.indent = n
+	}
+}
+
+// MaxDepth limits how deep the tree is printed. Children of nodes at depth n
+// are not printed. Zero or negative values mean no limit.
+func MaxDepth(n int) Option {
+	return func(w *Writer) {
+		w.maxDepth = n
+	}
 }
 
 // NewWriter builds the tree and stores it in the Writer.
-func NewWriter(w io.Writer, root Node) *Writer {
-	return &Writer{w: w, root: root}
+func NewWriter(w io.Writer, root Node, opts ...Option) *Writer {
+	tw := &Writer{
+		w:      w,
+		root:   root,
+		glyphs: UnicodeGlyphs,
+		indent: defaultIndent,
+	}
+	for _, opt := range opts {
+		opt(tw)
+	}
+	return tw
 }
 
 func (w *Writer) Write(prelude []byte) (int, error) {
@@ -33,55 +80,86 @@ func (w *Writer) Write(prelude []byte) (int, error) {
 	if err != nil {
 		return total + n, err
 	}
-	if n, err := write(ww, w.root, make([]bool, 0)); err != nil {
+	if n, err := w.write(w.root, make([]bool, 0)); err != nil {
 		return total + n, err
 	}
 	total += n
 	return total, nil
 }
 
-func write(w io.Writer, n Node, lastlist []bool) (int, error) {
-	var total int
+func (w *Writer) write(n Node, lastlist []bool) (int, error) {
+	total, err := w.writeLine(fmt.Sprint(n), lastlist)
+	if err != nil {
+		return total, err
+	}
+	var (
+		nlen   = n.Len()
+		elided = n.Elided()
+	)
+	if w.maxDepth > 0 && len(lastlist) >= w.maxDepth {
+		nlen, elided = 0, count(n)
+	}
+	for i := 0; i < nlen; i++ {
+		isLast := i == nlen-1 && elided == 0
+		n, err := w.write(n.At(i), append(lastlist, isLast))
+		if err != nil {
+			return total + n, err
+		}
+		total += n
+	}
+	if elided > 0 {
+		marker := fmt.Sprintf("%s (%d more)", w.glyphs.Ellipsis, elided)
+		n, err := w.writeLine(marker, append(lastlist, true))
+		if err != nil {
+			return total + n, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (w *Writer) writeLine(text string, lastlist []bool) (int, error) {
+	var (
+		total int
+		ww    = w.w
+		g     = w.glyphs
+	)
 	for i, isLast := range lastlist {
 		deepest := i == len(lastlist)-1
-		if deepest {
-			char := "├"
-			if isLast {
-				char = "└"
-			}
-			n, err := fmt.Fprintf(w, "%s──", char)
-			if err != nil {
-				return total + n, err
-			}
-			continue
-		}
-		char := "│"
-		if isLast {
+		var (
+			char   = g.Vertical
+			indent = strings.Repeat(" ", w.indent-1)
+		)
+		switch {
+		case deepest && isLast:
+			char, indent = g.LastBranch, strings.Repeat(g.Horizontal, w.indent-2)
+		case deepest:
+			char, indent = g.Branch, strings.Repeat(g.Horizontal, w.indent-2)
+		case isLast:
 			char = " "
 		}
-		fmt.Fprintf(w, "%s   ", char)
-	}
-	nstr := fmt.Sprint(n)
-	if nstr != "" {
-		n, err := fmt.Fprintf(w, " %s", nstr)
+		n, err := fmt.Fprintf(ww, "%s%s", char, indent)
 		if err != nil {
 			return total + n, err
 		}
 		total += n
 	}
-	nn, err := fmt.Fprintln(w)
-	if err != nil {
-		return total + nn, err
-	}
-	total += nn
-	nlen := n.Len()
-	for i := 0; i < nlen; i++ {
-		isLast := i == nlen-1
-		n, err := write(w, n.At(i), append(lastlist, isLast))
+	if text != "" {
+		n, err := fmt.Fprintf(ww, " %s", text)
 		if err != nil {
 			return total + n, err
 		}
 		total += n
 	}
-	return total, nil
+	n, err := fmt.Fprintln(ww)
+	return total + n, err
+}
+
+// count returns how many nodes descend from n, including elided ones.
+func count(n Node) int {
+	total := n.Elided()
+	for i := 0; i < n.Len(); i++ {
+		total += 1 + count(n.At(i))
+	}
+	return total
 }
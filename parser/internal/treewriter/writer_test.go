@@ -11,6 +11,7 @@ func TestWriter(t *testing.T) {
 	// TODO(gbrlsnchs): test output using golden files
 	testCases := []struct {
 		n     treewriter.Node
+		opts  []treewriter.Option
 		input []byte
 		want  string
 	}{
@@ -82,11 +83,197 @@ func TestWriter(t *testing.T) {
     └── quux
 `,
 		},
+		{
+			n: &testNode{
+				text: "",
+				nodes: []*testNode{
+					{text: "foo"},
+					{
+						text: "bar",
+						nodes: []*testNode{
+							{
+								text: "baz",
+								nodes: []*testNode{
+									{text: "qux"},
+								},
+							},
+						},
+					},
+				},
+			},
+			opts:  []treewriter.Option{treewriter.SetGlyphs(treewriter.ASCIIGlyphs)},
+			input: nil,
+			want: ".\n" +
+				"|-- foo\n" +
+				"`-- bar\n" +
+				"    `-- baz\n" +
+				"        `-- qux\n",
+		},
+		{
+			n: &testNode{
+				text: "",
+				nodes: []*testNode{
+					{text: "foo"},
+					{
+						text: "bar",
+						nodes: []*testNode{
+							{
+								text: "baz",
+								nodes: []*testNode{
+									{text: "qux"},
+								},
+							},
+						},
+					},
+				},
+			},
+			opts:  []treewriter.Option{treewriter.Indent(2)},
+			input: nil,
+			want: `.
+├ foo
+└ bar
+  └ baz
+    └ qux
+`,
+		},
+		{
+			n: &testNode{
+				text: "",
+				nodes: []*testNode{
+					{text: "foo"},
+					{
+						text: "bar",
+						nodes: []*testNode{
+							{
+								text: "baz",
+								nodes: []*testNode{
+									{text: "qux"},
+								},
+							},
+						},
+					},
+				},
+			},
+			opts:  []treewriter.Option{treewriter.Indent(0)},
+			input: nil,
+			want: `.
+├ foo
+└ bar
+  └ baz
+    └ qux
+`,
+		},
+		{
+			n: &testNode{
+				text: "",
+				nodes: []*testNode{
+					{text: "foo"},
+					{
+						text: "bar",
+						nodes: []*testNode{
+							{
+								text: "baz",
+								nodes: []*testNode{
+									{text: "qux"},
+								},
+							},
+						},
+					},
+				},
+			},
+			opts: []treewriter.Option{
+				treewriter.SetGlyphs(treewriter.ASCIIGlyphs),
+				treewriter.Indent(6),
+			},
+			input: nil,
+			want: ".\n" +
+				"|---- foo\n" +
+				"`---- bar\n" +
+				"      `---- baz\n" +
+				"            `---- qux\n",
+		},
+		{
+			n: &testNode{
+				text: "",
+				nodes: []*testNode{
+					{text: "foo"},
+					{
+						text: "bar",
+						nodes: []*testNode{
+							{
+								text: "baz",
+								nodes: []*testNode{
+									{text: "qux"},
+								},
+							},
+						},
+					},
+				},
+			},
+			opts:  []treewriter.Option{treewriter.MaxDepth(2)},
+			input: nil,
+			want: `.
+├── foo
+└── bar
+    └── baz
+        └── … (1 more)
+`,
+		},
+		{
+			n: &testNode{
+				text: "",
+				nodes: []*testNode{
+					{
+						text: "foo",
+						nodes: []*testNode{
+							{text: "bar"},
+						},
+						elided: 3,
+					},
+					{text: "baz", elided: 1},
+				},
+			},
+			input: nil,
+			want: `.
+├── foo
+│   ├── bar
+│   └── … (3 more)
+└── baz
+    └── … (1 more)
+`,
+		},
+		{
+			n: &testNode{
+				text: "",
+				nodes: []*testNode{
+					{
+						text: "foo",
+						nodes: []*testNode{
+							{
+								text: "bar",
+								nodes: []*testNode{
+									{text: "baz"},
+								},
+							},
+							{text: "qux", elided: 2},
+						},
+					},
+				},
+			},
+			opts: []treewriter.Option{
+				treewriter.SetGlyphs(treewriter.ASCIIGlyphs),
+				treewriter.MaxDepth(1),
+			},
+			input: nil,
+			want: ".\n" +
+				"`-- foo\n" +
+				"    `-- ... (5 more)\n",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run("Write", func(t *testing.T) {
 			var bd strings.Builder
-			tw := treewriter.NewWriter(&bd, tc.n)
+			tw := treewriter.NewWriter(&bd, tc.n, tc.opts...)
 			tw.Write(tc.input)
 			if want, got := tc.want, bd.String(); got != want {
 				t.Errorf("want %q, got %q", want, got)
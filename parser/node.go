@@ -15,17 +15,63 @@ type Node struct {
 	Link     File
 	Children []*Node
 	Status   Status
+	// Vars are the variables set in the configuration for the node, including inherited ones.
+	Vars map[string]string
+	// Provenance is how the node was resolved, if parsed with Trace.
+	Provenance *Provenance
 }
 
-type printableNode Node
+type printableNode struct {
+	*Node
+	collapseDone bool
+}
 
 // At returns a child node at index i.
-func (n *printableNode) At(i int) treewriter.Node { return (*printableNode)(n.Children[i]) }
+func (n printableNode) At(i int) treewriter.Node {
+	return printableNode{n.Children[i], n.collapseDone}
+}
 
 // Len returns the number of children of n.
-func (n *printableNode) Len() int { return len(n.Children) }
+func (n printableNode) Len() int {
+	if n.collapsed() {
+		return 0
+	}
+	return len(n.Children)
+}
+
+// Elided returns the number of descendants omitted from n.
+func (n printableNode) Elided() int {
+	if !n.collapsed() {
+		return 0
+	}
+	total := 0
+	walk(n.Node, func(*Node) error {
+		total++
+		return nil
+	})
+	return total - 1 // n itself is not elided
+}
+
+func (n printableNode) collapsed() bool {
+	// The root node is never collapsed, otherwise nothing is printed at all.
+	isRoot := len(n.Target.Path) == 0
+	return n.collapseDone && !isRoot && len(n.Children) > 0 && isDone(n.Node)
+}
+
+// isDone reports whether every leaf descending from n is already symlinked.
+func isDone(n *Node) bool {
+	if len(n.Children) == 0 {
+		return n.Status == StatusDone
+	}
+	for _, c := range n.Children {
+		if !isDone(c) {
+			return false
+		}
+	}
+	return true
+}
 
-func (n *printableNode) String() string {
+func (n printableNode) String() string {
 	if len(n.Target.Path) == 0 {
 		return ""
 	}
@@ -1,7 +1,11 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"os/user"
+	"path/filepath"
 	"sort"
 
 	"github.com/gbrlsnchs/pilgo/config"
@@ -16,109 +20,317 @@ const (
 	UserMode Mode = iota
 	// HomeMode refers to the home directory.
 	HomeMode
+	// DataMode refers to the user data directory, e.g. "~/.local/share".
+	DataMode
+	// StateMode refers to the user state directory, e.g. "~/.local/state".
+	StateMode
+	// CacheMode refers to the user cache directory, e.g. "~/.cache".
+	CacheMode
+	// BinMode refers to the user executables directory, e.g. "~/.local/bin".
+	BinMode
 )
 
+// modes maps names accepted by the "base" field to their modes.
+var modes = map[string]Mode{
+	"config": UserMode,
+	"home":   HomeMode,
+	"data":   DataMode,
+	"state":  StateMode,
+	"cache":  CacheMode,
+	"bin":    BinMode,
+}
+
+// ErrUnknownBase means a base directory name is neither a mode nor a user-defined alias.
+var ErrUnknownBase = errors.New("unknown base directory")
+
 // Parser is a configuration parser.
 type Parser struct {
-	cwd      string
-	baseDirs map[Mode]string
-	envsubst bool
-	tags     map[string]struct{}
+	cwd       string
+	baseDirs  map[Mode]string
+	envsubst  bool
+	lookupEnv func(string) (string, bool)
+	tags      map[string]struct{}
+	trace     bool
 }
 
 // Parse parses a configuration file and returns its tree representation.
+// The configuration is not modified, so it may be parsed again, e.g. with different tags.
 func (p *Parser) Parse(c *config.Config, opts ...ParseOption) (*Tree, error) {
 	for _, opt := range opts {
 		if err := opt(p); err != nil {
 			return nil, err
 		}
 	}
-	root := &Node{Children: p.parseChildren(c, nil, nil)}
+	if p.lookupEnv == nil {
+		p.lookupEnv = os.LookupEnv
+	}
+	sc, err := p.scope(scope{}, c, nil)
+	if err != nil {
+		return nil, targetError(nil, err)
+	}
+	root := &Node{Vars: sc.vars}
+	if p.trace {
+		root.Provenance = new(Provenance)
+	}
+	if root.Children, err = p.parseChildren(root, c, nil, nil, sc); err != nil {
+		return nil, err
+	}
 	return &Tree{root}, nil
 }
 
-func (p *Parser) parseChildren(c *config.Config, ptargets, plinks []string) []*Node {
-	var children []*Node
+// parseChildren parses the targets of c, which is the configuration of parent.
+func (p *Parser) parseChildren(parent *Node, c *config.Config, ptargets, plinks []string, sc scope) ([]*Node, error) {
 	tglen := len(c.Targets)
-	if tglen > 0 {
-		for i, tg := range c.Targets {
-			c.Targets[i] = p.expandVar(tg)
-		}
-		sort.Strings(c.Targets)
-		children = make([]*Node, 0, tglen)
-		for _, tg := range c.Targets {
-			cc := c.Options[tg]
-			if cc == nil {
-				cc = new(config.Config) // use default config
-			}
-			if len(cc.Tags) > 0 {
-				if len(p.tags) == 0 {
-					continue
-				}
-				shouldInclude := false
-				for _, t := range cc.Tags {
-					if _, ok := p.tags[t]; ok {
-						shouldInclude = true
-						break
-					}
-				}
-				if !shouldInclude {
-					continue
-				}
-			}
-			if cc.UseHome == nil {
-				cc.UseHome = c.UseHome
-			}
-			if cc.BaseDir == "" {
-				cc.BaseDir = c.BaseDir
+	if tglen == 0 {
+		return nil, nil
+	}
+	// Targets are expanded into a new slice, so that the configuration is left untouched.
+	targets := make([]string, tglen)
+	literals := make(map[string]string, tglen)
+	for i, tg := range c.Targets {
+		var err error
+		if targets[i], err = p.expandVar(tg, sc); err != nil {
+			return nil, targetError(append(ptargets[:len(ptargets):len(ptargets)], tg), err)
+		}
+		literals[targets[i]] = tg
+	}
+	if err := unlistedOptions(c, literals); err != nil {
+		return nil, targetError(ptargets, err)
+	}
+	sort.Strings(targets)
+	children := make([]*Node, 0, tglen)
+	for _, tg := range targets {
+		// Options are looked up by the expanded target name, then by the literal one.
+		cc := c.Options[tg]
+		if cc == nil {
+			cc = c.Options[literals[tg]]
+		}
+		if cc == nil {
+			cc = new(config.Config) // use default config
+		}
+		tgs := append(append(make([]string, 0, len(ptargets)+1), ptargets...), tg)
+		if !p.hasTags(cc.Tags) {
+			if p.trace {
+				parent.Provenance.Excluded = append(parent.Provenance.Excluded, &Node{
+					Target:     File{p.cwd, tgs},
+					Provenance: &Provenance{Tags: p.traceTags(cc.Tags)},
+				})
 			}
-			tgs := append(make([]string, 0, len(ptargets)+1), ptargets...)
-			lns := append(make([]string, 0, len(plinks)+1), plinks...)
-			cc.BaseDir = p.expandVar(cc.BaseDir)
-			children = append(children, p.parseTarget(cc,
-				append(tgs, tg),
-				append(lns, tg)))
+			continue
 		}
+		ccScope, err := p.scope(sc, cc, tgs)
+		if err != nil {
+			return nil, targetError(tgs, err)
+		}
+		lns := append(append(make([]string, 0, len(plinks)+1), plinks...), tg)
+		n, err := p.parseTarget(cc, tgs, lns, ccScope)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, n)
 	}
-	return children
+	return children, nil
 }
 
-func (p *Parser) parseTarget(c *config.Config, targets, links []string) *Node {
-	n := &Node{Target: File{p.cwd, targets}}
+func (p *Parser) parseTarget(c *config.Config, targets, links []string, sc scope) (*Node, error) {
+	n := &Node{Target: File{p.cwd, targets}, Vars: sc.vars}
 	lnlen := len(links)
 	if c.Link != "" {
 		// Replace last element from links. This is a link rename.
-		linkname := c.Link
+		linkname, err := p.expandVar(c.Link, sc)
+		if err != nil {
+			return nil, targetError(targets, err)
+		}
 		links[lnlen-1] = linkname
 	}
+	linkname := links[lnlen-1]
 	if c.Flatten {
 		s := links[:lnlen-1]
 		// We need to create a new slice to avoid reusing the
 		// same underlying array between children.
 		links = append(make([]string, 0, len(s)), s...)
 	}
-	if c.BaseDir == "" {
-		mode := UserMode
-		if c.UseHome != nil && *c.UseHome {
-			mode = HomeMode
+	// The resolved directory is not inherited, so that children are still able to
+	// choose their own base, except with useHome, which is ignored for them.
+	dir := sc.baseDir
+	if dir == "" {
+		var ok bool
+		if dir, ok = sc.aliases[sc.base]; !ok {
+			mode := UserMode
+			if sc.base != "" {
+				if mode, ok = modes[sc.base]; !ok {
+					return nil, targetError(targets, fmt.Errorf("%w %q", ErrUnknownBase, sc.base))
+				}
+			}
+			dir = p.baseDirs[mode]
+		}
+	}
+	n.Link = File{dir, links}
+	if p.trace {
+		n.Provenance = p.provenance(c, targets, linkname, sc)
+	}
+	children, err := p.parseChildren(n, c, targets, links, sc)
+	if err != nil {
+		return nil, err
+	}
+	n.Children = children
+	return n, nil
+}
+
+// unlistedOptions returns an error if c has options for a target that is listed neither
+// literally nor after expansion. Literals maps expanded targets to their literal names.
+func unlistedOptions(c *config.Config, literals map[string]string) error {
+	listed := make(map[string]struct{}, 2*len(literals))
+	for tg, lit := range literals {
+		listed[tg] = struct{}{}
+		listed[lit] = struct{}{}
+	}
+	names := make([]string, 0, len(c.Options))
+	for name := range c.Options {
+		if _, ok := listed[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%w %q", config.ErrUnknownTarget, names[0])
+}
+
+// hasTags reports whether a target with tags should be included, i.e. whether
+// it has no tags at all or at least one of them is enabled.
+func (p *Parser) hasTags(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, t := range tags {
+		if _, ok := p.tags[t]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// scope holds what nested targets inherit from their parents.
+type scope struct {
+	aliases map[string]string
+	vars    map[string]string
+	// baseDir is already expanded, while base is the name of a base directory,
+	// which useHome is a shorthand for, i.e. either "home" or "config".
+	baseDir string
+	base    string
+	// baseDirFrom and baseFrom are where the respective fields are set, if at all.
+	baseDirFrom *origin
+	baseFrom    *origin
+}
+
+// scope returns the scope of c, which is the inherited one extended with c's own fields.
+// Variables may reference inherited ones, while aliases and the base directory may also
+// reference the ones from c.
+func (p *Parser) scope(inherited scope, c *config.Config, targets []string) (scope, error) {
+	sc := inherited
+	if len(c.Vars) > 0 {
+		sc.vars = make(map[string]string, len(inherited.vars)+len(c.Vars))
+		for name, v := range inherited.vars {
+			sc.vars[name] = v
 		}
-		c.BaseDir = p.baseDirs[mode]
+		for _, name := range sortedKeys(c.Vars) {
+			v, err := p.expandVar(c.Vars[name], inherited)
+			if err != nil {
+				return scope{}, fmt.Errorf("%s: %w", name, err)
+			}
+			sc.vars[name] = v
+		}
+	}
+	if len(c.BaseDirs) > 0 {
+		sc.aliases = make(map[string]string, len(inherited.aliases)+len(c.BaseDirs))
+		for name, dir := range inherited.aliases {
+			sc.aliases[name] = dir
+		}
+		for _, name := range sortedKeys(c.BaseDirs) {
+			dir, err := p.expandPath(c.BaseDirs[name], sc)
+			if err != nil {
+				return scope{}, fmt.Errorf("%s: %w", name, err)
+			}
+			sc.aliases[name] = dir
+		}
+	}
+	if c.BaseDir != "" {
+		dir, err := p.expandPath(c.BaseDir, sc)
+		if err != nil {
+			return scope{}, err
+		}
+		sc.baseDir = dir
+		sc.baseDirFrom = &origin{"baseDir", targets}
+	}
+	switch {
+	case c.Base != "" && c.UseHome != nil:
+		return scope{}, fmt.Errorf("%w: base and useHome", config.ErrConflictingFields)
+	case c.Base != "":
+		sc.base = c.Base
+		sc.baseFrom = &origin{"base", targets}
+	case c.UseHome != nil && len(targets) <= 1:
+		// Below top-level targets, useHome has never had any effect, since
+		// nested targets inherit their parent's directory, so it's still ignored.
+		sc.base = "config"
+		if *c.UseHome {
+			sc.base = "home"
+		}
+		sc.baseFrom = &origin{"useHome", targets}
+	}
+	return sc, nil
+}
+
+func (p *Parser) expandVar(s string, sc scope) (string, error) {
+	if !p.envsubst {
+		return s, nil
+	}
+	return p.expander(sc).expand(s)
+}
+
+func (p *Parser) expandPath(s string, sc scope) (string, error) {
+	if !p.envsubst {
+		return s, nil
+	}
+	return p.expander(sc).expandPath(s)
+}
+
+// expander returns an expander that looks up variables in sc before the environment.
+func (p *Parser) expander(sc scope) expander {
+	return expander{
+		lookupEnv: func(name string) (string, bool) {
+			if v, ok := sc.vars[name]; ok {
+				return v, true
+			}
+			return p.lookupEnv(name)
+		},
+		lookupUser: user.Lookup,
+		homeDir:    p.baseDirs[HomeMode],
 	}
-	n.Link = File{c.BaseDir, links}
-	n.Children = p.parseChildren(c, targets, links)
-	return n
 }
 
-func (p *Parser) expandVar(s string) string {
-	if p.envsubst {
-		return os.ExpandEnv(s)
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-	return s
+	sort.Strings(keys)
+	return keys
+}
+
+// targetError prefixes err with the path of the target that caused it, if any.
+func targetError(targets []string, err error) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("parser: %w", err)
+	}
+	return fmt.Errorf("parser: %s: %w", filepath.Join(targets...), err)
 }
 
 // ParseOption is a funcional option that intend to modify a Parser.
 type ParseOption func(*Parser) error
 
+// BaseDirs sets the directory of each mode.
 func BaseDirs(dirs map[Mode]string) ParseOption {
 	return func(p *Parser) error {
 		p.baseDirs = dirs
@@ -134,12 +346,32 @@ func Cwd(dirname string) ParseOption {
 	}
 }
 
-// Envsubst enables replacing ${var} or $var with their environment values.
+// Envsubst enables expanding variables in targets, links and base directories, as well
+// as a leading "~" or "~user" in the latter. Variables are referenced as $VAR, ${VAR},
+// ${VAR:-default} or ${VAR:?message}, and "$$" is a literal "$". Unlike in a shell,
+// referencing an unset variable without a default is an error.
+//
+// Variables set in the configuration take precedence over environment variables.
 func Envsubst(p *Parser) error {
 	p.envsubst = true
 	return nil
 }
 
+// LookupEnv sets the function used to look up environment variables,
+// which is os.LookupEnv by default.
+func LookupEnv(lookup func(string) (string, bool)) ParseOption {
+	return func(p *Parser) error {
+		p.lookupEnv = lookup
+		return nil
+	}
+}
+
+// Trace enables recording the provenance of each node.
+func Trace(p *Parser) error {
+	p.trace = true
+	return nil
+}
+
 // Tags filters targets by their tags.
 func Tags(tags map[string]struct{}) ParseOption {
 	return func(p *Parser) error {
@@ -3,6 +3,9 @@ package parser_test
 import (
 	"errors"
 	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gbrlsnchs/pilgo/config"
@@ -13,6 +16,10 @@ import (
 
 func TestParser(t *testing.T) {
 	t.Run("Parse", testParserParse)
+	t.Run("Envsubst", testParserEnvsubst)
+	t.Run("Trace", testParserTrace)
+	t.Run("Reparse", testParserReparse)
+	t.Run("OptionKeys", testParserOptionKeys)
 }
 
 func testParserParse(t *testing.T) {
@@ -587,11 +594,328 @@ func testParserParse(t *testing.T) {
 			})},
 			err: nil,
 		},
+		{
+			c: config.Config{
+				Targets: []string{
+					"foo",
+					"bar",
+					"baz",
+				},
+				Base: "data",
+				Options: map[string]*config.Config{
+					"bar": {
+						Base: "bin",
+					},
+					"baz": {
+						UseHome: internal.NewBool(false), // same as "config"
+					},
+				},
+			},
+			opts: []parser.ParseOption{
+				parser.BaseDirs(map[parser.Mode]string{
+					parser.UserMode: "user",
+					parser.HomeMode: "home",
+					parser.DataMode: "data",
+					parser.BinMode:  "bin",
+				}),
+			},
+			tr: &parser.Tree{
+				Root: &parser.Node{Children: []*parser.Node{
+					{
+						Target: parser.File{"", []string{"bar"}},
+						Link:   parser.File{"bin", []string{"bar"}},
+					},
+					{
+						Target: parser.File{"", []string{"baz"}},
+						Link:   parser.File{"user", []string{"baz"}},
+					},
+					{
+						Target: parser.File{"", []string{"foo"}},
+						Link:   parser.File{"data", []string{"foo"}},
+					},
+				}},
+			},
+			err: nil,
+		},
+		{
+			c: config.Config{
+				Targets: []string{
+					"zsh",
+				},
+				Options: map[string]*config.Config{
+					"zsh": {
+						Targets: []string{
+							"foo",
+							"bar",
+						},
+						UseHome: internal.NewBool(true),
+						Options: map[string]*config.Config{
+							"foo": {
+								UseHome: internal.NewBool(false), // ignored, as it has always been
+							},
+							"bar": {
+								Base: "config",
+							},
+						},
+					},
+				},
+			},
+			opts: []parser.ParseOption{
+				parser.BaseDirs(map[parser.Mode]string{
+					parser.UserMode: "user",
+					parser.HomeMode: "home",
+				}),
+			},
+			tr: &parser.Tree{
+				Root: &parser.Node{Children: []*parser.Node{
+					{
+						Target: parser.File{"", []string{"zsh"}},
+						Link:   parser.File{"home", []string{"zsh"}},
+						Children: []*parser.Node{
+							{
+								Target: parser.File{"", []string{"zsh", "bar"}},
+								Link:   parser.File{"user", []string{"zsh", "bar"}},
+							},
+							{
+								Target: parser.File{"", []string{"zsh", "foo"}},
+								Link:   parser.File{"home", []string{"zsh", "foo"}},
+							},
+						},
+					},
+				}},
+			},
+			err: nil,
+		},
+		{
+			c: config.Config{
+				Targets: []string{
+					"foo",
+				},
+				Options: map[string]*config.Config{
+					"foo": {
+						Base:    "data",
+						UseHome: internal.NewBool(true),
+					},
+				},
+			},
+			tr:  nil,
+			err: config.ErrConflictingFields,
+		},
+		{
+			c: config.Config{
+				Targets: []string{
+					"test",
+				},
+				BaseDirs: map[string]string{
+					"scripts": "~/scripts",
+					"env":     "$MY_ENV_VAR/env",
+				},
+				Options: map[string]*config.Config{
+					"test": {
+						Targets: []string{
+							"foo",
+							"bar",
+						},
+						Base: "scripts",
+						BaseDirs: map[string]string{
+							"data": "~", // aliases override modes
+						},
+						Options: map[string]*config.Config{
+							"bar": {
+								Base: "data",
+							},
+							"foo": {
+								Base: "env",
+							},
+						},
+					},
+				},
+			},
+			opts: []parser.ParseOption{
+				parser.BaseDirs(map[parser.Mode]string{
+					parser.HomeMode: "home",
+					parser.DataMode: "data",
+				}),
+				parser.Envsubst,
+			},
+			tr: &parser.Tree{
+				Root: &parser.Node{Children: []*parser.Node{
+					{
+						Target: parser.File{"", []string{"test"}},
+						Link:   parser.File{filepath.Join("home", "scripts"), []string{"test"}},
+						Children: []*parser.Node{
+							{
+								Target: parser.File{"", []string{"test", "bar"}},
+								Link:   parser.File{"home", []string{"test", "bar"}},
+							},
+							{
+								Target: parser.File{"", []string{"test", "foo"}},
+								Link:   parser.File{filepath.Join("home", "env"), []string{"test", "foo"}},
+							},
+						},
+					},
+				}},
+			},
+			err: nil,
+		},
+		{
+			c: config.Config{
+				BaseDir: "test", // this overrides base
+				Targets: []string{
+					"foo",
+				},
+				Base: "unknown",
+			},
+			tr: &parser.Tree{
+				Root: &parser.Node{Children: []*parser.Node{
+					{
+						Target: parser.File{"", []string{"foo"}},
+						Link:   parser.File{"test", []string{"foo"}},
+					},
+				}},
+			},
+			err: nil,
+		},
+		{
+			c: config.Config{
+				Targets: []string{
+					"foo",
+				},
+				Base: "unknown",
+			},
+			tr:  nil,
+			err: parser.ErrUnknownBase,
+		},
+		{
+			c: config.Config{
+				BaseDir: "$ROOT",
+				Targets: []string{
+					"$NAME",
+					"test",
+				},
+				Vars: map[string]string{
+					"ROOT": "links",
+					"NAME": "foo",
+				},
+				Options: map[string]*config.Config{
+					"test": {
+						BaseDir: "$ROOT/${NAME}",
+						Link:    "${NAME}rc",
+						Targets: []string{
+							"$NAME",
+						},
+						Vars: map[string]string{
+							"NAME": "${NAME}bar", // overrides the inherited one
+						},
+					},
+				},
+			},
+			opts: []parser.ParseOption{
+				parser.Envsubst,
+				parser.LookupEnv(func(string) (string, bool) { return "", false }),
+			},
+			tr: &parser.Tree{
+				Root: &parser.Node{
+					Vars: map[string]string{"ROOT": "links", "NAME": "foo"},
+					Children: []*parser.Node{
+						{
+							Target: parser.File{"", []string{"foo"}},
+							Link:   parser.File{"links", []string{"foo"}},
+							Vars:   map[string]string{"ROOT": "links", "NAME": "foo"},
+						},
+						{
+							Target: parser.File{"", []string{"test"}},
+							Link:   parser.File{filepath.Join("links", "foobar"), []string{"foobarrc"}},
+							Vars:   map[string]string{"ROOT": "links", "NAME": "foobar"},
+							Children: []*parser.Node{
+								{
+									Target: parser.File{"", []string{"test", "foobar"}},
+									Link:   parser.File{filepath.Join("links", "foobar"), []string{"foobarrc", "foobar"}},
+									Vars:   map[string]string{"ROOT": "links", "NAME": "foobar"},
+								},
+							},
+						},
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			c: config.Config{
+				Targets: []string{"$FOO", "${NAME}"},
+				Vars: map[string]string{
+					"NAME": "baz",
+				},
+				// Options match either the expanded or the literal target.
+				Options: map[string]*config.Config{
+					"bar":     {Link: "qux"},
+					"${NAME}": {Link: "quux"},
+				},
+			},
+			opts: []parser.ParseOption{
+				parser.Envsubst,
+				parser.LookupEnv(func(name string) (string, bool) { return "bar", name == "FOO" }),
+			},
+			tr: &parser.Tree{
+				Root: &parser.Node{
+					Vars: map[string]string{"NAME": "baz"},
+					Children: []*parser.Node{
+						{
+							Target: parser.File{"", []string{"bar"}},
+							Link:   parser.File{"", []string{"qux"}},
+							Vars:   map[string]string{"NAME": "baz"},
+						},
+						{
+							Target: parser.File{"", []string{"baz"}},
+							Link:   parser.File{"", []string{"quux"}},
+							Vars:   map[string]string{"NAME": "baz"},
+						},
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			c: config.Config{
+				Targets: []string{"$FOO"},
+				Options: map[string]*config.Config{
+					"baz": {Link: "qux"},
+				},
+			},
+			opts: []parser.ParseOption{
+				parser.Envsubst,
+				parser.LookupEnv(func(name string) (string, bool) { return "bar", name == "FOO" }),
+			},
+			tr:  nil,
+			err: config.ErrUnknownTarget,
+		},
+		{
+			c: config.Config{
+				Targets: []string{"test"},
+				Options: map[string]*config.Config{
+					"test": {
+						Vars: map[string]string{"FOO": "$UNSET"},
+					},
+				},
+			},
+			opts: []parser.ParseOption{
+				parser.Envsubst,
+				parser.LookupEnv(func(string) (string, bool) { return "", false }),
+			},
+			tr:  nil,
+			err: parser.ErrUnsetVar,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run("", func(t *testing.T) {
-			var p parser.Parser
+			var (
+				p    parser.Parser
+				orig = cloneConfig(&tc.c)
+			)
 			tr, err := p.Parse(&tc.c, tc.opts...)
+			if want, got := orig, &tc.c; !cmp.Equal(got, want) {
+				t.Fatalf("(*Parser).Parse modified the configuration: (-want +got):\n%s", cmp.Diff(want, got))
+			}
 			if want, got := tc.err, err; !errors.Is(got, want) {
 				t.Fatalf("want %v, got %v", want, got)
 			}
@@ -604,3 +928,302 @@ func testParserParse(t *testing.T) {
 		})
 	}
 }
+
+func testParserEnvsubst(t *testing.T) {
+	env := map[string]string{
+		"FOO":   "foo",
+		"EMPTY": "",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	u, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		baseDir string
+		target  string
+		want    string
+		err     error
+	}{
+		{baseDir: "$FOO/${FOO}", want: filepath.Join("foo", "foo")},
+		{baseDir: "${EMPTY}bar", want: "bar"},
+		{baseDir: "${BAR:-bar}", want: "bar"},
+		{baseDir: "${EMPTY:-${FOO}}", want: "foo"},
+		{baseDir: "${FOO:?not used}", want: "foo"},
+		{baseDir: "$$FOO$", want: "$FOO$"},
+		{baseDir: "~", want: "home"},
+		{baseDir: "~/$FOO", want: filepath.Join("home", "foo")},
+		{baseDir: "bar~", want: "bar~"},
+		{baseDir: "~pilgo_nonexistent_user/$FOO", want: "~pilgo_nonexistent_user/foo"},
+		{target: "$FOO", want: "user"},
+		{target: "~", want: "user"}, // targets are not paths
+		{baseDir: "$BAR", err: parser.ErrUnsetVar},
+		{baseDir: "${BAR}", err: parser.ErrUnsetVar},
+		{baseDir: "${EMPTY:?must be set}", err: parser.ErrUnsetVar},
+		{baseDir: "${FOO", err: parser.ErrBadSubst},
+		{baseDir: "${FOO:=foo}", err: parser.ErrBadSubst},
+		{baseDir: "${}", err: parser.ErrBadSubst},
+		{target: "${BAR}", err: parser.ErrUnsetVar},
+	}
+	// On Windows, user names are prefixed with their domain.
+	if !strings.ContainsRune(u.Username, filepath.Separator) {
+		testCases = append(testCases, struct {
+			baseDir string
+			target  string
+			want    string
+			err     error
+		}{baseDir: "~" + u.Username, want: u.HomeDir})
+	}
+	for _, tc := range testCases {
+		t.Run(tc.baseDir+tc.target, func(t *testing.T) {
+			tg := tc.target
+			if tg == "" {
+				tg = "test"
+			}
+			c := config.Config{
+				Targets: []string{tg},
+				Options: map[string]*config.Config{
+					tg: {BaseDir: tc.baseDir},
+				},
+			}
+			var p parser.Parser
+			tr, err := p.Parse(&c,
+				parser.BaseDirs(map[parser.Mode]string{
+					parser.UserMode: "user",
+					parser.HomeMode: "home",
+				}),
+				parser.Envsubst,
+				parser.LookupEnv(lookupEnv))
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if err != nil {
+				// Errors name the target.
+				if want, got := "parser: "+tg+": ", err.Error(); !strings.HasPrefix(got, want) {
+					t.Fatalf("want prefix %q, got %q", want, got)
+				}
+				return
+			}
+			if want, got := tc.want, tr.Root.Children[0].Link.BaseDir; got != want {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+		})
+	}
+}
+
+func testParserTrace(t *testing.T) {
+	c := config.Config{
+		BaseDir: "links",
+		Targets: []string{"foo", "bar"},
+		Options: map[string]*config.Config{
+			"foo": {
+				UseHome: internal.NewBool(true),
+				Flatten: true,
+				Targets: []string{"baz", "qux"},
+				Options: map[string]*config.Config{
+					"baz": {Link: ".baz", Tags: []string{"test", "other"}},
+					"qux": {Tags: []string{"other"}},
+				},
+			},
+			"bar": {Tags: []string{"other"}},
+		},
+	}
+	var p parser.Parser
+	tr, err := p.Parse(&c,
+		parser.BaseDirs(map[parser.Mode]string{parser.HomeMode: "home"}),
+		parser.Tags(map[string]struct{}{"test": {}}),
+		parser.Trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &parser.Provenance{
+		Excluded: []*parser.Node{
+			{
+				Target:     parser.File{"", []string{"bar"}},
+				Provenance: &parser.Provenance{Tags: []parser.TracedTag{{"other", false}}},
+			},
+		},
+	}
+	if got := tr.Root.Provenance; !cmp.Equal(got, want) {
+		t.Fatalf("root provenance mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+	foo := tr.Root.Children[0]
+	want = &parser.Provenance{
+		Fields: []parser.TracedField{
+			{Name: "baseDir", Value: "links", Set: true, Origin: nil},
+			{Name: "useHome", Value: "true", Set: true, Origin: []string{"foo"}},
+			{Name: "link", Value: "foo", Set: false, Origin: []string{"foo"}},
+			{Name: "flatten", Value: "true", Set: true, Origin: []string{"foo"}},
+		},
+		Excluded: []*parser.Node{
+			{
+				Target:     parser.File{"", []string{"foo", "qux"}},
+				Provenance: &parser.Provenance{Tags: []parser.TracedTag{{"other", false}}},
+			},
+		},
+	}
+	if got := foo.Provenance; !cmp.Equal(got, want) {
+		t.Fatalf("foo provenance mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+	baz := foo.Children[0]
+	want = &parser.Provenance{
+		Fields: []parser.TracedField{
+			{Name: "baseDir", Value: "links", Set: true, Origin: nil},
+			{Name: "useHome", Value: "true", Set: true, Origin: []string{"foo"}},
+			{Name: "link", Value: ".baz", Set: true, Origin: []string{"foo", "baz"}},
+			{Name: "flatten", Value: "false", Set: false, Origin: []string{"foo", "baz"}},
+		},
+		Tags: []parser.TracedTag{{"test", true}, {"other", false}},
+	}
+	if got := baz.Provenance; !cmp.Equal(got, want) {
+		t.Fatalf("foo/baz provenance mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+	if !baz.Provenance.Fields[1].Inherited(baz.Target.Path) {
+		t.Fatal("want useHome to be inherited")
+	}
+	if baz.Provenance.Fields[2].Inherited(baz.Target.Path) {
+		t.Fatal("want link not to be inherited")
+	}
+}
+
+// testParserReparse checks that the same configuration can be parsed repeatedly.
+func testParserReparse(t *testing.T) {
+	c := config.Config{
+		BaseDir: "$LINKS",
+		Targets: []string{"${FOO}", "bar"},
+		Vars:    map[string]string{"FOO": "foo"},
+		Options: map[string]*config.Config{
+			"foo": {
+				UseHome: internal.NewBool(true),
+				Targets: []string{"baz"},
+				Tags:    []string{"foo"},
+			},
+			"bar": {Base: "data", Targets: []string{"qux"}},
+		},
+	}
+	orig := cloneConfig(&c)
+	parse := func(tags ...string) *parser.Tree {
+		set := make(map[string]struct{}, len(tags))
+		for _, t := range tags {
+			set[t] = struct{}{}
+		}
+		var p parser.Parser
+		tr, err := p.Parse(&c,
+			parser.BaseDirs(map[parser.Mode]string{
+				parser.UserMode: "user",
+				parser.HomeMode: "home",
+				parser.DataMode: "data",
+			}),
+			parser.Envsubst,
+			parser.LookupEnv(func(key string) (string, bool) {
+				if key == "LINKS" {
+					return "links", true
+				}
+				return "", false
+			}),
+			parser.Tags(set))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := orig, &c; !cmp.Equal(got, want) {
+			t.Fatalf("(*Parser).Parse modified the configuration: (-want +got):\n%s", cmp.Diff(want, got))
+		}
+		return tr
+	}
+	withoutTags := parse()
+	withTags := parse("foo")
+	if want, got := withoutTags, parse(); !cmp.Equal(got, want) {
+		t.Fatalf("(*Parser).Parse mismatch: (-want +got):\n%s", cmp.Diff(want, got))
+	}
+	if want, got := withTags, parse("foo"); !cmp.Equal(got, want) {
+		t.Fatalf("(*Parser).Parse mismatch: (-want +got):\n%s", cmp.Diff(want, got))
+	}
+	if want, got := 2, len(withTags.Root.Children); got != want {
+		t.Fatalf("want %d children, got %d", want, got)
+	}
+	foo := withTags.Root.Children[1]
+	if want, got := (parser.File{"links", []string{"foo", "baz"}}), foo.Children[0].Link; !cmp.Equal(got, want) {
+		t.Fatalf("(-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func testParserOptionKeys(t *testing.T) {
+	c := config.Config{
+		Targets: []string{"$FOO", "bar"},
+		Options: map[string]*config.Config{
+			// Options are looked up by expanded target names,
+			// so options set for "$FOO" as listed are ignored.
+			"$FOO": {Link: "unused"},
+			"foo":  {Link: "used"},
+		},
+	}
+	var p parser.Parser
+	tr, err := p.Parse(&c,
+		parser.BaseDirs(map[parser.Mode]string{parser.UserMode: "user"}),
+		parser.Envsubst,
+		parser.LookupEnv(func(key string) (string, bool) {
+			if key == "FOO" {
+				return "foo", true
+			}
+			return "", false
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*parser.Node{
+		{
+			Target: parser.File{"", []string{"bar"}},
+			Link:   parser.File{"user", []string{"bar"}},
+		},
+		{
+			Target: parser.File{"", []string{"foo"}},
+			Link:   parser.File{"user", []string{"used"}},
+		},
+	}
+	if got := tr.Root.Children; !cmp.Equal(got, want) {
+		t.Fatalf("(*Parser).Parse mismatch: (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+// cloneConfig returns a deep copy of c.
+func cloneConfig(c *config.Config) *config.Config {
+	if c == nil {
+		return nil
+	}
+	cc := *c
+	cc.Targets = cloneStrings(c.Targets)
+	cc.Tags = cloneStrings(c.Tags)
+	cc.BaseDirs = cloneMap(c.BaseDirs)
+	cc.Vars = cloneMap(c.Vars)
+	if c.UseHome != nil {
+		cc.UseHome = internal.NewBool(*c.UseHome)
+	}
+	if c.Options != nil {
+		cc.Options = make(map[string]*config.Config, len(c.Options))
+		for k, o := range c.Options {
+			cc.Options[k] = cloneConfig(o)
+		}
+	}
+	return &cc
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(s)), s...)
+}
+
+func cloneMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	mm := make(map[string]string, len(m))
+	for k, v := range m {
+		mm[k] = v
+	}
+	return mm
+}
@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"strconv"
+
+	"github.com/gbrlsnchs/pilgo/config"
+)
+
+// Provenance records how a node was resolved from the configuration.
+// It is only set when parsing with Trace.
+type Provenance struct {
+	// Fields are the configuration fields that affect the node's link, in order of precedence
+	// for its base directory, i.e. "baseDir" and then either "base" or "useHome", followed
+	// by "link" and "flatten".
+	Fields []TracedField
+	// Tags are the node's own tags, since they're not inherited.
+	Tags []TracedTag
+	// Excluded are children left out of the tree because none of their tags is enabled.
+	// They only hold their target and provenance.
+	Excluded []*Node
+}
+
+// TracedField is the value of a configuration field and where it was set.
+type TracedField struct {
+	Name  string
+	Value string
+	// Set reports whether the field is set at all, otherwise Value is its default.
+	Set bool
+	// Origin is the path of the target whose options set the field,
+	// which is empty for the root configuration.
+	Origin []string
+}
+
+// Inherited reports whether the field was set by an ancestor of the target at path.
+func (f TracedField) Inherited(path []string) bool {
+	return f.Set && len(f.Origin) < len(path)
+}
+
+// TracedTag is a tag and whether it is enabled.
+type TracedTag struct {
+	Name    string
+	Enabled bool
+}
+
+// origin is where a field inherited by nested targets was set.
+type origin struct {
+	field string
+	path  []string
+}
+
+// provenance returns the provenance of a node for target, whose configuration is c.
+func (p *Parser) provenance(c *config.Config, targets []string, linkname string, sc scope) *Provenance {
+	base := TracedField{Name: "base", Value: "config"}
+	if o := sc.baseFrom; o != nil {
+		base = TracedField{Name: o.field, Value: sc.base, Set: true, Origin: o.path}
+		if o.field == "useHome" {
+			base.Value = strconv.FormatBool(sc.base == "home")
+		}
+	}
+	baseDir := TracedField{Name: "baseDir"}
+	if o := sc.baseDirFrom; o != nil {
+		baseDir = TracedField{Name: "baseDir", Value: sc.baseDir, Set: true, Origin: o.path}
+	}
+	return &Provenance{
+		Fields: []TracedField{
+			baseDir,
+			base,
+			{Name: "link", Value: linkname, Set: c.Link != "", Origin: targets},
+			{Name: "flatten", Value: strconv.FormatBool(c.Flatten), Set: c.Flatten, Origin: targets},
+		},
+		Tags: p.traceTags(c.Tags),
+	}
+}
+
+func (p *Parser) traceTags(tags []string) []TracedTag {
+	if len(tags) == 0 {
+		return nil
+	}
+	traced := make([]TracedTag, len(tags))
+	for i, t := range tags {
+		_, ok := p.tags[t]
+		traced[i] = TracedTag{t, ok}
+	}
+	return traced
+}
@@ -1,6 +1,13 @@
 package parser
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownStatus means a status name couldn't be recognized.
+var ErrUnknownStatus = errors.New("unknown status")
 
 // Status is a node's status.
 type Status uint8
@@ -15,14 +22,30 @@ const (
 	StatusDone
 	// StatusConflict means a symlink already exists but points to a different target.
 	StatusConflict
-	// StatusError means the target doesn't exist.
+	// StatusError means the target either doesn't exist or is a special file.
 	StatusError
 	// StatusExpand means a symlink already exists but since it's a directory
 	// and the target is also a directory, it gets expanded in order to have
 	// the target's inner files symlinked inside it.
 	StatusExpand
+	// StatusDuplicate means the link collides with other links, either
+	// by having the same path or by being inside one of them.
+	StatusDuplicate
+
+	lastStatus = StatusDuplicate
 )
 
+// ParseStatus returns the status represented by s, regardless of its case.
+func ParseStatus(s string) (Status, error) {
+	name := strings.ToLower(s)
+	for st := StatusReady; st <= lastStatus; st <<= 1 {
+		if st.str() == name {
+			return st, nil
+		}
+	}
+	return 0, fmt.Errorf("parser: %w %q", ErrUnknownStatus, s)
+}
+
 func (s Status) String() string { return strings.ToUpper(s.str()) }
 
 func (s Status) str() string {
@@ -39,6 +62,8 @@ func (s Status) str() string {
 		return "error"
 	case StatusExpand:
 		return "expand"
+	case StatusDuplicate:
+		return "duplicate"
 	default:
 		return "undefined"
 	}
@@ -0,0 +1,37 @@
+package parser_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gbrlsnchs/pilgo/parser"
+)
+
+func TestParseStatus(t *testing.T) {
+	testCases := []struct {
+		s    string
+		want parser.Status
+		err  error
+	}{
+		{"ready", parser.StatusReady, nil},
+		{"SKIP", parser.StatusSkip, nil},
+		{"Done", parser.StatusDone, nil},
+		{"conflict", parser.StatusConflict, nil},
+		{"error", parser.StatusError, nil},
+		{"expand", parser.StatusExpand, nil},
+		{"duplicate", parser.StatusDuplicate, nil},
+		{"undefined", 0, parser.ErrUnknownStatus},
+		{"", 0, parser.ErrUnknownStatus},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.s, func(t *testing.T) {
+			st, err := parser.ParseStatus(tc.s)
+			if want, got := tc.err, err; !errors.Is(got, want) {
+				t.Fatalf("want %v, got %v", want, got)
+			}
+			if want, got := tc.want, st; got != want {
+				t.Fatalf("want %s, got %s", want, got)
+			}
+		})
+	}
+}
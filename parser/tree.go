@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"bytes"
+	"io"
 	"strings"
 	"text/tabwriter"
 
+	"github.com/gbrlsnchs/pilgo/parser/internal/graphwriter"
 	"github.com/gbrlsnchs/pilgo/parser/internal/treewriter"
 )
 
@@ -14,14 +17,73 @@ type Tree struct {
 }
 
 func (tr *Tree) String() string {
+	var bd strings.Builder
+	tr.Fprint(&bd)
+	return bd.String()
+}
+
+// Fprint prints the tree to w. By default, it is printed in a tree view.
+func (tr *Tree) Fprint(w io.Writer, opts ...PrintOption) error {
+	var p printer
+	for _, opt := range opts {
+		opt(&p)
+	}
+	switch p.format {
+	case FormatDOT:
+		return graphwriter.NewWriter(w, buildGraph(tr)).WriteDOT()
+	case FormatMermaid:
+		return graphwriter.NewWriter(w, buildGraph(tr)).WriteMermaid()
+	}
+	glyphs := treewriter.UnicodeGlyphs
+	if cs, ok := charsets[p.charset]; ok {
+		glyphs = cs.glyphs
+	}
 	var (
-		bd strings.Builder
-		w  = tabwriter.NewWriter(&bd, 0, 0, 1, ' ', 0)
-		tw = treewriter.NewWriter(w, (*printableNode)(tr.Root))
+		bd  bytes.Buffer
+		trw = treewriter.NewWriter(&bd, printableNode{tr.Root, p.collapseDone},
+			treewriter.SetGlyphs(glyphs),
+			treewriter.MaxDepth(p.maxDepth))
 	)
-	tw.Write(nil)
-	w.Flush()
-	return bd.String()
+	if _, err := trw.Write(nil); err != nil {
+		return err
+	}
+	return fprintAligned(w, bd.Bytes())
+}
+
+// fprintAligned aligns cells of lines in b and prints them to w. Lines without cells,
+// like markers of omitted nodes, are printed as they are and don't affect alignment.
+func fprintAligned(w io.Writer, b []byte) error {
+	lines := bytes.SplitAfter(b, []byte("\n"))
+	var aligned bytes.Buffer
+	tw := tabwriter.NewWriter(&aligned, 0, 0, 1, ' ', 0)
+	for _, line := range lines {
+		if bytes.IndexByte(line, '\t') < 0 {
+			continue
+		}
+		if _, err := tw.Write(line); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if bytes.IndexByte(line, '\t') >= 0 {
+			line = aligned.Next(bytes.IndexByte(aligned.Bytes(), '\n') + 1)
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Filter returns a copy of tr containing only nodes for which fn returns true.
+// Ancestors of matching nodes are also kept, so the tree's shape is preserved.
+func (tr *Tree) Filter(fn func(*Node) bool) *Tree {
+	root := *tr.Root
+	root.Children = filter(tr.Root.Children, fn)
+	return &Tree{&root}
 }
 
 // Walk traverses the tree using depth-first search and runs fn for each node found.
@@ -45,3 +107,17 @@ func walk(n *Node, fn func(*Node) error) error {
 	}
 	return nil
 }
+
+func filter(nodes []*Node, fn func(*Node) bool) []*Node {
+	var filtered []*Node
+	for _, n := range nodes {
+		children := filter(n.Children, fn)
+		if len(children) == 0 && !fn(n) {
+			continue
+		}
+		nn := *n
+		nn.Children = children
+		filtered = append(filtered, &nn)
+	}
+	return filtered
+}
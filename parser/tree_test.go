@@ -3,6 +3,7 @@ package parser_test
 import (
 	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gbrlsnchs/pilgo/parser"
@@ -10,10 +11,248 @@ import (
 )
 
 func TestTree(t *testing.T) {
+	t.Run("Filter", testTreeFilter)
+	t.Run("Fprint", testTreeFprint)
 	t.Run("String", testTreeString)
+	t.Run("Validate", testTreeValidate)
 	t.Run("Walk", testTreeWalk)
 }
 
+func testTreeFilter(t *testing.T) {
+	tr := &parser.Tree{
+		Root: &parser.Node{Children: []*parser.Node{
+			{
+				Target: parser.File{"", []string{"foo"}},
+				Link:   parser.File{"test", []string{"foo"}},
+				Children: []*parser.Node{
+					{
+						Target: parser.File{"", []string{"foo", "bar"}},
+						Link:   parser.File{"test", []string{"foo", "bar"}},
+						Status: parser.StatusDone,
+					},
+					{
+						Target: parser.File{"", []string{"foo", "baz"}},
+						Link:   parser.File{"test", []string{"foo", "baz"}},
+						Status: parser.StatusConflict,
+					},
+				},
+				Status: parser.StatusSkip,
+			},
+			{
+				Target: parser.File{"", []string{"qux"}},
+				Link:   parser.File{"test", []string{"qux"}},
+				Status: parser.StatusReady,
+			},
+		}},
+	}
+	testCases := []struct {
+		name string
+		fn   func(*parser.Node) bool
+		want *parser.Tree
+	}{
+		{
+			name: "all",
+			fn:   func(*parser.Node) bool { return true },
+			want: tr,
+		},
+		{
+			name: "none",
+			fn:   func(*parser.Node) bool { return false },
+			want: &parser.Tree{Root: &parser.Node{}},
+		},
+		{
+			name: "keep ancestors",
+			fn: func(n *parser.Node) bool {
+				return n.Status == parser.StatusConflict
+			},
+			want: &parser.Tree{
+				Root: &parser.Node{Children: []*parser.Node{
+					{
+						Target: parser.File{"", []string{"foo"}},
+						Link:   parser.File{"test", []string{"foo"}},
+						Children: []*parser.Node{
+							{
+								Target: parser.File{"", []string{"foo", "baz"}},
+								Link:   parser.File{"test", []string{"foo", "baz"}},
+								Status: parser.StatusConflict,
+							},
+						},
+						Status: parser.StatusSkip,
+					},
+				}},
+			},
+		},
+		{
+			name: "drop children",
+			fn: func(n *parser.Node) bool {
+				return n.Status&(parser.StatusSkip|parser.StatusReady) != 0
+			},
+			want: &parser.Tree{
+				Root: &parser.Node{Children: []*parser.Node{
+					{
+						Target: parser.File{"", []string{"foo"}},
+						Link:   parser.File{"test", []string{"foo"}},
+						Status: parser.StatusSkip,
+					},
+					{
+						Target: parser.File{"", []string{"qux"}},
+						Link:   parser.File{"test", []string{"qux"}},
+						Status: parser.StatusReady,
+					},
+				}},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if want, got := tc.want, tr.Filter(tc.fn); !cmp.Equal(got, want) {
+				t.Errorf(
+					"(*Tree).Filter mismatch (-want +got):\n%s",
+					cmp.Diff(want, got),
+				)
+			}
+		})
+	}
+	// Filtering must not modify the original tree.
+	if want, got := 2, len(tr.Root.Children[0].Children); got != want {
+		t.Fatalf("want %d, got %d", want, got)
+	}
+}
+
+func testTreeFprint(t *testing.T) {
+	tr := &parser.Tree{
+		Root: &parser.Node{Children: []*parser.Node{
+			{
+				Target: parser.File{"dotfiles", []string{"foo"}},
+				Link:   parser.File{"test", []string{"foo"}},
+				Children: []*parser.Node{
+					{
+						Target: parser.File{"dotfiles", []string{"foo", "bar"}},
+						Link:   parser.File{"test", []string{"foo", "bar"}},
+						Status: parser.StatusDone,
+					},
+				},
+				Status: parser.StatusSkip,
+			},
+			{
+				Target: parser.File{"dotfiles", []string{"baz"}},
+				Link:   parser.File{"home", []string{".baz"}},
+				Status: parser.StatusConflict,
+			},
+			{
+				Target: parser.File{"dotfiles", []string{"qux"}},
+				Link:   parser.File{"home", []string{".qux"}},
+			},
+		}},
+	}
+	testCases := []struct {
+		name string
+		opts []parser.PrintOption
+		want string
+	}{
+		{
+			name: "tree",
+			opts: nil,
+			want: `.
+├── foo                     (SKIP)
+│   └── bar <- test/foo/bar (DONE)
+├── baz     <- home/.baz    (CONFLICT)
+└── qux     <- home/.qux
+`,
+		},
+		{
+			name: "ascii",
+			opts: []parser.PrintOption{parser.TreeCharset(parser.CharsetASCII)},
+			want: ".\n" +
+				"|-- foo                     (SKIP)\n" +
+				"|   `-- bar <- test/foo/bar (DONE)\n" +
+				"|-- baz     <- home/.baz    (CONFLICT)\n" +
+				"`-- qux     <- home/.qux\n",
+		},
+		{
+			name: "depth",
+			opts: []parser.PrintOption{parser.MaxDepth(1)},
+			want: ".\n" +
+				"├── foo              (SKIP)\n" +
+				"│   └── … (1 more)\n" +
+				"├── baz <- home/.baz (CONFLICT)\n" +
+				"└── qux <- home/.qux\n",
+		},
+		{
+			name: "collapse done",
+			opts: []parser.PrintOption{parser.CollapseDone},
+			want: ".\n" +
+				"├── foo              (SKIP)\n" +
+				"│   └── … (1 more)\n" +
+				"├── baz <- home/.baz (CONFLICT)\n" +
+				"└── qux <- home/.qux\n",
+		},
+		{
+			name: "dot",
+			opts: []parser.PrintOption{parser.OutputFormat(parser.FormatDOT)},
+			want: `digraph pilgo {
+	rankdir=LR;
+	subgraph cluster_0 {
+		label="dotfiles";
+		n0 [label="foo/bar"];
+		n2 [label="baz"];
+		n4 [label="qux"];
+	}
+	subgraph cluster_1 {
+		label="test";
+		n1 [label="foo/bar", color="green"];
+	}
+	subgraph cluster_2 {
+		label="home";
+		n3 [label=".baz", color="orange"];
+		n5 [label=".qux"];
+	}
+	n0 -> n1 [color="green"];
+	n2 -> n3 [color="orange"];
+	n4 -> n5;
+}
+`,
+		},
+		{
+			name: "mermaid",
+			opts: []parser.PrintOption{parser.OutputFormat(parser.FormatMermaid)},
+			want: `flowchart LR
+	subgraph c0 ["dotfiles"]
+		n0["foo/bar"]
+		n2["baz"]
+		n4["qux"]
+	end
+	subgraph c1 ["test"]
+		n1["foo/bar"]
+	end
+	subgraph c2 ["home"]
+		n3[".baz"]
+		n5[".qux"]
+	end
+	n0 --> n1
+	n2 --> n3
+	n4 --> n5
+	style n1 stroke:green
+	style n3 stroke:orange
+	linkStyle 0 stroke:green
+	linkStyle 1 stroke:orange
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var bd strings.Builder
+			if err := tr.Fprint(&bd, tc.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if want, got := filepath.FromSlash(tc.want), bd.String(); got != want {
+				t.Errorf("\nwant\n%s\ngot\n%s", want, got)
+				t.Logf("\ndiff (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
 func testTreeString(t *testing.T) {
 	// TODO(gbrlsnchs): test output using golden files
 	testCases := []struct {
@@ -298,6 +537,113 @@ func testTreeWalk(t *testing.T) {
 	}
 }
 
+func testTreeValidate(t *testing.T) {
+	node := func(target, link string, children ...*parser.Node) *parser.Node {
+		return &parser.Node{
+			Target:   parser.File{BaseDir: "dotfiles", Path: strings.Split(target, "/")},
+			Link:     parser.File{BaseDir: "home", Path: strings.Split(link, "/")},
+			Children: children,
+		}
+	}
+	type collision struct {
+		Link    string
+		Targets []string
+	}
+	testCases := []struct {
+		name       string
+		nodes      []*parser.Node
+		collisions []collision
+		duplicates []string
+	}{
+		{
+			name: "no collisions",
+			nodes: []*parser.Node{
+				node("foo", "foo"),
+				node("bar", "bar",
+					node("bar/baz", "bar/baz"),
+					node("bar/qux", "bar/qux")),
+			},
+			collisions: nil,
+			duplicates: nil,
+		},
+		{
+			name: "same link",
+			nodes: []*parser.Node{
+				node("bar", "foo"),
+				node("baz", "qux"),
+				node("foo", "foo"),
+			},
+			collisions: []collision{
+				{filepath.Join("home", "foo"), []string{"bar", "foo"}},
+			},
+			duplicates: []string{"bar", "foo"},
+		},
+		{
+			name: "nested link",
+			nodes: []*parser.Node{
+				node("bar", "bar",
+					node("bar/baz", "foo/baz")),
+				node("foo", "foo"),
+			},
+			collisions: []collision{
+				{filepath.Join("home", "foo"), []string{"foo", filepath.Join("bar", "baz")}},
+			},
+			duplicates: []string{filepath.Join("bar", "baz"), "foo"},
+		},
+		{
+			name: "deeply nested links",
+			nodes: []*parser.Node{
+				node("bar", "foo/bar"),
+				node("baz", "foo/bar/baz"),
+				node("foo", "foo"),
+				node("qux", "foo/qux"),
+			},
+			collisions: []collision{
+				{filepath.Join("home", "foo", "bar"), []string{"bar", "baz"}},
+				{filepath.Join("home", "foo"), []string{"foo", "bar", "qux"}},
+			},
+			duplicates: []string{"bar", "baz", "foo", "qux"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := &parser.Tree{Root: &parser.Node{Children: tc.nodes}}
+			err := tr.Validate()
+			var (
+				cerr *parser.CollisionError
+				got  []collision
+			)
+			if errors.As(err, &cerr) {
+				for _, c := range cerr.Collisions {
+					if !errors.Is(c, parser.ErrDuplicateLink) {
+						t.Errorf("want %v, got %v", parser.ErrDuplicateLink, c)
+					}
+					targets := make([]string, len(c.Nodes))
+					for i, n := range c.Nodes {
+						targets[i] = filepath.Join(n.Target.Path...)
+					}
+					got = append(got, collision{c.Link, targets})
+				}
+			} else if err != nil {
+				t.Fatalf("want %T, got %v", cerr, err)
+			}
+			if want := tc.collisions; !cmp.Equal(got, want) {
+				t.Errorf("(*Tree).Validate mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			var duplicates []string
+			tr.Walk(func(n *parser.Node) error {
+				if n.Status == parser.StatusDuplicate {
+					duplicates = append(duplicates, filepath.Join(n.Target.Path...))
+				}
+				return nil
+			})
+			if want, got := tc.duplicates, duplicates; !cmp.Equal(got, want) {
+				t.Errorf("statuses mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
 type iterator struct {
 	argstack []*parser.Node
 }
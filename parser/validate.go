@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrDuplicateLink means two or more targets would be symlinked to the same path,
+// or a target would be symlinked inside another target's link.
+var ErrDuplicateLink = errors.New("duplicate link")
+
+// Collision is a group of nodes whose links collide.
+type Collision struct {
+	// Link is the full path of the link the nodes collide at.
+	Link string
+	// Nodes are every node symlinked to Link, followed by every
+	// node symlinked somewhere inside it, if any.
+	Nodes []*Node
+}
+
+func (c *Collision) Error() string {
+	targets := make([]string, len(c.Nodes))
+	for i, n := range c.Nodes {
+		targets[i] = filepath.Join(n.Target.Path...)
+	}
+	return fmt.Sprintf("parser: %s: %v: %s", c.Link, ErrDuplicateLink, strings.Join(targets, ", "))
+}
+
+// Unwrap returns ErrDuplicateLink.
+func (c *Collision) Unwrap() error { return ErrDuplicateLink }
+
+// CollisionError lists every collision found in a tree.
+type CollisionError struct {
+	Collisions []*Collision
+}
+
+func (e *CollisionError) Error() string {
+	if n := len(e.Collisions); n != 1 {
+		return fmt.Sprintf("parser: there are %d link collisions", n)
+	}
+	return "parser: there is 1 link collision"
+}
+
+// Unwrap returns ErrDuplicateLink.
+func (e *CollisionError) Unwrap() error { return ErrDuplicateLink }
+
+// Validate checks that links in tr don't collide, either by having the same path or
+// by being inside another link. Colliding nodes have their status set to StatusDuplicate
+// and are listed in a *CollisionError, in the same order nodes are walked.
+//
+// Only nodes without children are checked, since they're the only ones symlinked.
+func (tr *Tree) Validate() error {
+	var (
+		links = make(map[string][]*Node)
+		paths []string
+	)
+	tr.Walk(func(n *Node) error {
+		if len(n.Children) > 0 || len(n.Link.Path) == 0 {
+			return nil
+		}
+		lnpath := n.Link.FullPath()
+		if _, ok := links[lnpath]; !ok {
+			paths = append(paths, lnpath)
+		}
+		links[lnpath] = append(links[lnpath], n)
+		return nil
+	})
+	nested := make(map[string][]*Node)
+	for _, lnpath := range paths {
+		for dir := lnpath; ; {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+			// Links nested even deeper are grouped under the innermost one,
+			// which in turn is nested inside dir.
+			if _, ok := links[dir]; ok {
+				nested[dir] = append(nested[dir], links[lnpath]...)
+				break
+			}
+		}
+	}
+	var cerr CollisionError
+	for _, lnpath := range paths {
+		nodes := links[lnpath]
+		if len(nodes) < 2 && len(nested[lnpath]) == 0 {
+			continue
+		}
+		nodes = append(nodes, nested[lnpath]...)
+		for _, n := range nodes {
+			n.Status = StatusDuplicate
+		}
+		cerr.Collisions = append(cerr.Collisions, &Collision{lnpath, nodes})
+	}
+	if len(cerr.Collisions) > 0 {
+		return &cerr
+	}
+	return nil
+}